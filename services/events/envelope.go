@@ -0,0 +1,156 @@
+// Package events is the contract between the services: the envelope every
+// event travels in, the payload types and a JSON Schema per event version.
+// It is its own module so all services build against the same copy.
+package events
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrUnknownSchema      = errors.New("unknown event schema")
+	ErrInvalidEvent       = errors.New("invalid event")
+	ErrUnsupportedVersion = errors.New("unsupported event version")
+)
+
+// Envelope wraps every event published on the events exchange. Type is also
+// the routing key, and ID doubles as the AMQP message id consumers
+// deduplicate on.
+type Envelope struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	Version       int             `json:"version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	Producer      string          `json:"producer"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// New wraps data as the current version of eventType, published by the
+// service named producer, and validates it against that version's schema,
+// so a producer can never emit an event its consumers would reject.
+func New(producer, eventType string, data interface{}) (*Envelope, error) {
+	version, ok := currentVersions[eventType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSchema, eventType)
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Envelope{
+		ID:         id,
+		Type:       eventType,
+		Version:    version,
+		OccurredAt: time.Now().UTC(),
+		Producer:   producer,
+		Data:       raw,
+	}
+	if err := env.validate(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// Decode parses and validates a message body received with routingKey.
+// Messages published before envelopes were introduced carry the bare
+// version 1 payload; they are wrapped as such so consumers only ever deal
+// with envelopes.
+func Decode(routingKey, messageID string, body []byte) (*Envelope, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+
+	var env Envelope
+	if _, ok := probe["data"]; ok {
+		if err := validateJSON(envelopeSchema, body); err != nil {
+			return nil, fmt.Errorf("%w: envelope: %v", ErrInvalidEvent, err)
+		}
+		if err := json.Unmarshal(body, &env); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+	} else {
+		env = Envelope{
+			ID:      messageID,
+			Type:    routingKey,
+			Version: 1,
+			Data:    bytes.Clone(body),
+		}
+	}
+
+	if env.Type != routingKey {
+		return nil, fmt.Errorf("%w: type %q received with routing key %q", ErrInvalidEvent, env.Type, routingKey)
+	}
+	if err := env.validate(); err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
+
+// Marshal returns the wire form of the envelope.
+func (e *Envelope) Marshal() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// DataAs decodes the payload into v, which must be the shape of version of
+// the event. Payloads of an older version are upcast step by step first, so
+// a consumer written against version N keeps accepting N-1, N-2, … side by
+// side. Payloads newer than version are refused.
+func (e *Envelope) DataAs(version int, v interface{}) error {
+	if e.Version > version {
+		return fmt.Errorf("%w: %s v%d (consumer understands up to v%d)", ErrUnsupportedVersion, e.Type, e.Version, version)
+	}
+
+	data := e.Data
+	for from := e.Version; from < version; from++ {
+		upcast, ok := upcasters[upcastKey{e.Type, from}]
+		if !ok {
+			return fmt.Errorf("%w: no upcast for %s v%d", ErrUnsupportedVersion, e.Type, from)
+		}
+
+		var err error
+		if data, err = upcast(data); err != nil {
+			return fmt.Errorf("%w: upcasting %s v%d: %v", ErrInvalidEvent, e.Type, from, err)
+		}
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	return nil
+}
+
+func (e *Envelope) validate() error {
+	s, ok := schemas[schemaKey{e.Type, e.Version}]
+	if !ok {
+		return fmt.Errorf("%w: %s v%d", ErrUnknownSchema, e.Type, e.Version)
+	}
+	if err := validateJSON(s, e.Data); err != nil {
+		return fmt.Errorf("%w: %s v%d: %v", ErrInvalidEvent, e.Type, e.Version, err)
+	}
+	return nil
+}
+
+// newID returns a random (version 4) UUID.
+func newID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
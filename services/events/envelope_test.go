@@ -0,0 +1,234 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	env, err := New("order_service", OrderCancelled, OrderCancelledV1{
+		OrderID:     7,
+		Reason:      "changed my mind",
+		CancelledAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if env.Type != OrderCancelled || env.Version != currentVersions[OrderCancelled] {
+		t.Errorf("got %s v%d, want %s v%d", env.Type, env.Version, OrderCancelled, currentVersions[OrderCancelled])
+	}
+	if env.Producer != "order_service" {
+		t.Errorf("Producer = %q, want order_service", env.Producer)
+	}
+	if !uuidPattern.MatchString(env.ID) {
+		t.Errorf("ID %q is not a uuid", env.ID)
+	}
+
+	raw, err := env.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if err := validateJSON(envelopeSchema, raw); err != nil {
+		t.Errorf("envelope does not match its schema: %v", err)
+	}
+}
+
+func TestNewRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		data      interface{}
+		want      error
+	}{
+		{"unknown type", "order.shipped", struct{}{}, ErrUnknownSchema},
+		{"payload against schema", OrderCreated, OrderCreatedV1{OrderID: 1, UserID: 1}, ErrInvalidEvent},
+		{"payload not an object", UserRegistered, 42, ErrInvalidEvent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New("order_service", tt.eventType, tt.data)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	const id = "3f2b8c1e-6a7d-4e55-9a0b-2c1d7e9f4a10"
+
+	envelope := func(eventType string, version int, data string) string {
+		return fmt.Sprintf(`{"id": %q, "type": %q, "version": %d, "occurred_at": "2026-01-01T12:00:00Z", "producer": "user_service", "data": %s}`,
+			id, eventType, version, data)
+	}
+
+	tests := []struct {
+		name        string
+		routingKey  string
+		messageID   string
+		body        string
+		want        error
+		wantID      string
+		wantVersion int
+	}{
+		{
+			name:        "envelope",
+			routingKey:  UserRegistered,
+			body:        envelope(UserRegistered, 1, `{"user_id": 7}`),
+			wantID:      id,
+			wantVersion: 1,
+		},
+		{
+			name:        "bare payload is version 1",
+			routingKey:  UserRegistered,
+			messageID:   "legacy-1",
+			body:        `{"user_id": 7}`,
+			wantID:      "legacy-1",
+			wantVersion: 1,
+		},
+		{
+			name:       "bare payload against schema",
+			routingKey: UserRegistered,
+			body:       `{"user_id": 0}`,
+			want:       ErrInvalidEvent,
+		},
+		{
+			name:       "not JSON",
+			routingKey: UserRegistered,
+			body:       `user 7`,
+			want:       ErrInvalidEvent,
+		},
+		{
+			name:       "envelope against schema",
+			routingKey: UserRegistered,
+			body:       `{"id": "x", "type": "user.registered", "version": 1, "data": {"user_id": 7}}`,
+			want:       ErrInvalidEvent,
+		},
+		{
+			name:       "type differs from routing key",
+			routingKey: OrderCreated,
+			body:       envelope(UserRegistered, 1, `{"user_id": 7}`),
+			want:       ErrInvalidEvent,
+		},
+		{
+			name:       "version without schema",
+			routingKey: UserRegistered,
+			body:       envelope(UserRegistered, 9, `{"user_id": 7}`),
+			want:       ErrUnknownSchema,
+		},
+		{
+			name:       "payload against schema",
+			routingKey: UserRegistered,
+			body:       envelope(UserRegistered, 1, `{"user_id": "7"}`),
+			want:       ErrInvalidEvent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := Decode(tt.routingKey, tt.messageID, []byte(tt.body))
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("got %v, want %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if env.ID != tt.wantID || env.Version != tt.wantVersion {
+				t.Errorf("got id %q v%d, want id %q v%d", env.ID, env.Version, tt.wantID, tt.wantVersion)
+			}
+
+			var data UserRegisteredV1
+			if err := env.DataAs(1, &data); err != nil {
+				t.Fatalf("DataAs: %v", err)
+			}
+			if data.UserID != 7 {
+				t.Errorf("UserID = %d, want 7", data.UserID)
+			}
+		})
+	}
+}
+
+// testEvent only exists in this test; its v1 payload named the user "uid",
+// v2 renamed it to "user_id" and v3 added "source".
+const testEvent = "test.upcast"
+
+type testEventV3 struct {
+	UserID int64  `json:"user_id"`
+	Source string `json:"source"`
+}
+
+func registerTestUpcasters(t *testing.T) {
+	t.Helper()
+
+	upcasters[upcastKey{testEvent, 1}] = func(data json.RawMessage) (json.RawMessage, error) {
+		var v1 struct {
+			UID int64 `json:"uid"`
+		}
+		if err := json.Unmarshal(data, &v1); err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]interface{}{"user_id": v1.UID})
+	}
+	upcasters[upcastKey{testEvent, 2}] = func(data json.RawMessage) (json.RawMessage, error) {
+		var v2 map[string]interface{}
+		if err := json.Unmarshal(data, &v2); err != nil {
+			return nil, err
+		}
+		v2["source"] = "unknown"
+		return json.Marshal(v2)
+	}
+
+	t.Cleanup(func() {
+		delete(upcasters, upcastKey{testEvent, 1})
+		delete(upcasters, upcastKey{testEvent, 2})
+	})
+}
+
+func TestDataAs(t *testing.T) {
+	registerTestUpcasters(t)
+
+	tests := []struct {
+		name      string
+		eventType string
+		version   int
+		data      string
+		want      testEventV3
+		wantErr   error
+	}{
+		{"current version", testEvent, 3, `{"user_id": 7, "source": "web"}`, testEventV3{7, "web"}, nil},
+		{"one version behind", testEvent, 2, `{"user_id": 7}`, testEventV3{7, "unknown"}, nil},
+		{"two versions behind", testEvent, 1, `{"uid": 7}`, testEventV3{7, "unknown"}, nil},
+		{"newer than the consumer", testEvent, 4, `{"user_id": 7}`, testEventV3{}, ErrUnsupportedVersion},
+		{"no upcaster", UserRegistered, 1, `{"user_id": 7}`, testEventV3{}, ErrUnsupportedVersion},
+		{"upcaster fails", testEvent, 1, `{"uid": "7"}`, testEventV3{}, ErrInvalidEvent},
+		{"payload does not fit", testEvent, 3, `{"user_id": "7"}`, testEventV3{}, ErrInvalidEvent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &Envelope{Type: tt.eventType, Version: tt.version, Data: json.RawMessage(tt.data)}
+
+			var got testEventV3
+			err := env.DataAs(3, &got)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DataAs: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package events
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schemas are JSON Schema documents named <type>.v<version>.json. Only the
+// keywords the contracts need are supported: type, required, properties,
+// items, enum, minimum, exclusiveMinimum, minLength, minItems and the
+// date-time and uuid formats.
+//
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+type schemaKey struct {
+	Type    string
+	Version int
+}
+
+var (
+	schemas        = map[schemaKey]*schema{}
+	envelopeSchema *schema
+)
+
+func init() {
+	entries, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, entry := range entries {
+		raw, err := schemaFiles.ReadFile(path.Join("schemas", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		var s schema
+		if err := json.Unmarshal(raw, &s); err != nil {
+			panic(fmt.Sprintf("events: invalid schema %s: %v", entry.Name(), err))
+		}
+
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		if name == "envelope" {
+			envelopeSchema = &s
+			continue
+		}
+
+		var key schemaKey
+		i := strings.LastIndex(name, ".v")
+		if i < 0 {
+			panic("events: schema file without version: " + entry.Name())
+		}
+		if _, err := fmt.Sscanf(name[i+2:], "%d", &key.Version); err != nil {
+			panic("events: schema file without version: " + entry.Name())
+		}
+		key.Type = name[:i]
+		schemas[key] = &s
+	}
+
+	for eventType, version := range currentVersions {
+		if _, ok := schemas[schemaKey{eventType, version}]; !ok {
+			panic(fmt.Sprintf("events: no schema for %s v%d", eventType, version))
+		}
+	}
+}
+
+type schema struct {
+	Type             interface{}        `json:"type"`
+	Required         []string           `json:"required"`
+	Properties       map[string]*schema `json:"properties"`
+	Items            *schema            `json:"items"`
+	Enum             []interface{}      `json:"enum"`
+	Minimum          *float64           `json:"minimum"`
+	ExclusiveMinimum *float64           `json:"exclusiveMinimum"`
+	MinLength        *int               `json:"minLength"`
+	MinItems         *int               `json:"minItems"`
+	Format           string             `json:"format"`
+}
+
+func validateJSON(s *schema, raw []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return err
+	}
+	return s.validate("$", value)
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func (s *schema) validate(at string, value interface{}) error {
+	if err := s.checkType(at, value); err != nil {
+		return err
+	}
+
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		return fmt.Errorf("%s: %v is not one of %v", at, value, s.Enum)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", at, name)
+			}
+		}
+
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if field, ok := v[name]; ok {
+				if err := s.Properties[name].validate(at+"."+name, field); err != nil {
+					return err
+				}
+			}
+		}
+
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return fmt.Errorf("%s: expected at least %d items", at, *s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", at, i), item); err != nil {
+					return err
+				}
+			}
+		}
+
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			return fmt.Errorf("%s: %v is less than %v", at, v, *s.Minimum)
+		}
+		if s.ExclusiveMinimum != nil && v <= *s.ExclusiveMinimum {
+			return fmt.Errorf("%s: %v must be greater than %v", at, v, *s.ExclusiveMinimum)
+		}
+
+	case string:
+		if s.MinLength != nil && len([]rune(v)) < *s.MinLength {
+			return fmt.Errorf("%s: expected at least %d characters", at, *s.MinLength)
+		}
+		switch s.Format {
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				return fmt.Errorf("%s: %q is not a date-time", at, v)
+			}
+		case "uuid":
+			if !uuidPattern.MatchString(v) {
+				return fmt.Errorf("%s: %q is not a uuid", at, v)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *schema) checkType(at string, value interface{}) error {
+	var types []string
+	switch t := s.Type.(type) {
+	case nil:
+		return nil
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, name := range t {
+			if str, ok := name.(string); ok {
+				types = append(types, str)
+			}
+		}
+	}
+
+	for _, t := range types {
+		if hasType(t, value) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: expected %s", at, strings.Join(types, " or "))
+}
+
+func hasType(t string, value interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return false
+	}
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func mustSchema(t *testing.T, raw string) *schema {
+	t.Helper()
+	var s schema
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		t.Fatalf("invalid schema %s: %v", raw, err)
+	}
+	return &s
+}
+
+func TestValidateJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		value  string
+		// wantErr is a substring of the expected error, or empty if the
+		// value is valid.
+		wantErr string
+	}{
+		{"type string", `{"type": "string"}`, `"a"`, ""},
+		{"type string mismatch", `{"type": "string"}`, `1`, "$: expected string"},
+		{"type integer", `{"type": "integer"}`, `3`, ""},
+		{"type integer rejects fraction", `{"type": "integer"}`, `3.5`, "$: expected integer"},
+		{"type number accepts fraction", `{"type": "number"}`, `3.5`, ""},
+		{"type boolean", `{"type": "boolean"}`, `true`, ""},
+		{"type object mismatch", `{"type": "object"}`, `[]`, "$: expected object"},
+		{"type array mismatch", `{"type": "array"}`, `{}`, "$: expected array"},
+		{"type union", `{"type": ["string", "null"]}`, `null`, ""},
+		{"type union mismatch", `{"type": ["string", "null"]}`, `1`, "$: expected string or null"},
+		{"no type accepts anything", `{}`, `[1, "a"]`, ""},
+
+		{"required present", `{"type": "object", "required": ["a"]}`, `{"a": 1}`, ""},
+		{"required missing", `{"type": "object", "required": ["a"]}`, `{"b": 1}`, `$: missing required property "a"`},
+
+		{"properties valid", `{"properties": {"a": {"type": "string"}}}`, `{"a": "x"}`, ""},
+		{"properties invalid", `{"properties": {"a": {"type": "string"}}}`, `{"a": 1}`, "$.a: expected string"},
+		{"properties absent are not checked", `{"properties": {"a": {"type": "string"}}}`, `{}`, ""},
+		{"properties unknown are allowed", `{"properties": {"a": {"type": "string"}}}`, `{"b": 1}`, ""},
+		{"properties nested", `{"properties": {"a": {"properties": {"b": {"type": "integer"}}}}}`, `{"a": {"b": "x"}}`, "$.a.b: expected integer"},
+
+		{"items valid", `{"items": {"type": "integer"}}`, `[1, 2]`, ""},
+		{"items invalid", `{"items": {"type": "integer"}}`, `[1, "x"]`, "$[1]: expected integer"},
+
+		{"enum member", `{"enum": ["a", "b"]}`, `"b"`, ""},
+		{"enum non-member", `{"enum": ["a", "b"]}`, `"c"`, "$: c is not one of [a b]"},
+
+		{"minimum equal", `{"minimum": 0}`, `0`, ""},
+		{"minimum below", `{"minimum": 0}`, `-1`, "$: -1 is less than 0"},
+		{"exclusiveMinimum above", `{"exclusiveMinimum": 0}`, `1`, ""},
+		{"exclusiveMinimum equal", `{"exclusiveMinimum": 0}`, `0`, "$: 0 must be greater than 0"},
+
+		{"minLength met", `{"minLength": 2}`, `"ab"`, ""},
+		{"minLength counts characters", `{"minLength": 2}`, `"é"`, "$: expected at least 2 characters"},
+		{"minLength short", `{"minLength": 1}`, `""`, "$: expected at least 1 characters"},
+
+		{"minItems met", `{"minItems": 1}`, `[1]`, ""},
+		{"minItems short", `{"minItems": 1}`, `[]`, "$: expected at least 1 items"},
+
+		{"format date-time", `{"format": "date-time"}`, `"2026-01-01T12:00:00.5Z"`, ""},
+		{"format date-time invalid", `{"format": "date-time"}`, `"2026-01-01"`, `$: "2026-01-01" is not a date-time`},
+		{"format uuid", `{"format": "uuid"}`, `"3f2b8c1e-6a7d-4e55-9a0b-2c1d7e9f4a10"`, ""},
+		{"format uuid invalid", `{"format": "uuid"}`, `"3f2b8c1e"`, `$: "3f2b8c1e" is not a uuid`},
+		{"format unknown is ignored", `{"format": "email"}`, `"x"`, ""},
+
+		{"invalid JSON", `{}`, `{`, "unexpected end of JSON input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateJSON(mustSchema(t, tt.schema), []byte(tt.value))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Fatalf("expected error containing %q, got %q", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSchemasCoverCurrentVersions(t *testing.T) {
+	if envelopeSchema == nil {
+		t.Fatal("no envelope schema")
+	}
+	for eventType, version := range currentVersions {
+		if _, ok := schemas[schemaKey{eventType, version}]; !ok {
+			t.Errorf("no schema for %s v%d", eventType, version)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types. The type is also the routing key on the events exchange.
+const (
+	UserRegistered      = "user.registered"
+	OrderCreated        = "order.created"
+	OrderCancelled      = "order.cancelled"
+	InventoryReserved   = "inventory.reserved"
+	InventoryFailed     = "inventory.failed"
+	ProductCreated      = "product.created"
+	ProductPriceChanged = "product.price_changed"
+)
+
+// currentVersions is the version New emits for each event type. Older
+// versions keep their schema file so they can still be consumed.
+var currentVersions = map[string]int{
+	UserRegistered:      1,
+	OrderCreated:        1,
+	OrderCancelled:      1,
+	InventoryReserved:   1,
+	InventoryFailed:     1,
+	ProductCreated:      1,
+	ProductPriceChanged: 1,
+}
+
+type upcastKey struct {
+	Type string
+	From int
+}
+
+// upcasters turn the payload of version From into version From+1. Register
+// one whenever an event gets a new version so consumers on the new shape
+// keep accepting messages still in flight from older producers.
+var upcasters = map[upcastKey]func(data json.RawMessage) (json.RawMessage, error){}
+
+// Payloads, version 1.
+
+type UserRegisteredV1 struct {
+	UserID int64 `json:"user_id"`
+}
+
+type OrderItemV1 struct {
+	ProductID int64   `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	Price     float64 `json:"price,omitempty"`
+}
+
+type OrderCreatedV1 struct {
+	OrderID   int64         `json:"order_id"`
+	UserID    int64         `json:"user_id"`
+	Status    string        `json:"status"`
+	Items     []OrderItemV1 `json:"items"`
+	Total     float64       `json:"total"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type OrderCancelledV1 struct {
+	OrderID     int64     `json:"order_id"`
+	Reason      string    `json:"reason"`
+	CancelledAt time.Time `json:"cancelled_at"`
+	CancelledBy int64     `json:"cancelled_by,omitempty"`
+}
+
+type InventoryReservedV1 struct {
+	OrderID int64         `json:"order_id"`
+	Items   []OrderItemV1 `json:"items"`
+}
+
+type ShortfallV1 struct {
+	ProductID int64  `json:"product_id"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+	Reason    string `json:"reason"`
+}
+
+type InventoryFailedV1 struct {
+	OrderID    int64         `json:"order_id"`
+	Reason     string        `json:"reason"`
+	Shortfalls []ShortfallV1 `json:"shortfalls,omitempty"`
+}
+
+type ProductCreatedV1 struct {
+	ProductID  int64     `json:"product_id"`
+	Name       string    `json:"name"`
+	CategoryID int64     `json:"category_id"`
+	Price      float64   `json:"price"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type ProductPriceChangedV1 struct {
+	ProductID  int64     `json:"product_id"`
+	OldPrice   float64   `json:"old_price"`
+	Price      float64   `json:"price"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"fmt"
+
+	"order_service/domain"
+	"platform/jwks"
+)
+
+// Verifier validates access tokens against the key set published by
+// user_service and turns them into actors of this service.
+type Verifier struct {
+	keys *jwks.Verifier
+}
+
+func NewVerifier(jwksURL, issuer string) *Verifier {
+	return &Verifier{keys: jwks.NewVerifier(jwksURL, issuer)}
+}
+
+func (v *Verifier) Verify(token string) (domain.Actor, error) {
+	id, err := v.keys.Verify(token)
+	if err != nil {
+		return domain.Actor{}, err
+	}
+
+	role := domain.Role(id.Role)
+	if !role.IsValid() {
+		return domain.Actor{}, fmt.Errorf("%w: bad role", jwks.ErrInvalidToken)
+	}
+	return domain.Actor{UserID: id.UserID, Role: role}, nil
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"order_service/domain"
+	"platform/jwks"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestVerifyRole(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+
+	sign := func(role string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwks.Claims{
+			Role: role,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    "user_service",
+				Subject:   "42",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		})
+		token.Header["kid"] = "k1"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return signed
+	}
+
+	verifier := NewVerifier(server.URL, "user_service")
+
+	actor, err := verifier.Verify(sign("worker"))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if actor != (domain.Actor{UserID: 42, Role: domain.RoleWorker}) {
+		t.Errorf("actor = %+v", actor)
+	}
+
+	if _, err := verifier.Verify(sign("root")); !errors.Is(err, jwks.ErrInvalidToken) {
+		t.Errorf("unknown role: got %v, want ErrInvalidToken", err)
+	}
+}
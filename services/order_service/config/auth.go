@@ -0,0 +1,18 @@
+package config
+
+import "errors"
+
+type AuthConfig struct {
+	JWKSURL string `yaml:"jwks_url"`
+	Issuer  string `yaml:"issuer"`
+}
+
+func (c *AuthConfig) validate() error {
+	if c.JWKSURL == "" {
+		return errors.New("JWKS_URL is not set")
+	}
+	if c.Issuer == "" {
+		return errors.New("JWT_ISSUER must not be empty")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+
+	"platform/rabbitmq"
+)
+
+type RabbitMQConfig struct {
+	rabbitmq.Config `yaml:",inline"`
+
+	Queues QueueConfig `yaml:"queues"`
+}
+
+// QueueConfig names the queue each consumer reads from. Replicas of the
+// service share a queue, so they split its messages between them.
+type QueueConfig struct {
+	UserRegistered      string `yaml:"user_registered"`
+	ProductCreated      string `yaml:"product_created"`
+	ProductPriceChanged string `yaml:"product_price_changed"`
+	InventoryReserved   string `yaml:"inventory_reserved"`
+	InventoryFailed     string `yaml:"inventory_failed"`
+}
+
+func (c *RabbitMQConfig) validate() error {
+	if err := c.Config.Validate(); err != nil {
+		return err
+	}
+
+	seen := map[string]string{}
+	for _, q := range []struct{ key, name string }{
+		{"RABBITMQ_QUEUE_USER_REGISTERED", c.Queues.UserRegistered},
+		{"RABBITMQ_QUEUE_PRODUCT_CREATED", c.Queues.ProductCreated},
+		{"RABBITMQ_QUEUE_PRODUCT_PRICE_CHANGED", c.Queues.ProductPriceChanged},
+		{"RABBITMQ_QUEUE_INVENTORY_RESERVED", c.Queues.InventoryReserved},
+		{"RABBITMQ_QUEUE_INVENTORY_FAILED", c.Queues.InventoryFailed},
+	} {
+		if q.name == "" {
+			return fmt.Errorf("%s must not be empty", q.key)
+		}
+		if other, ok := seen[q.name]; ok {
+			return fmt.Errorf("%s and %s both name queue %q", other, q.key, q.name)
+		}
+		seen[q.name] = q.key
+	}
+
+	return nil
+}
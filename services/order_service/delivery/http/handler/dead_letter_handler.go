@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"order_service/delivery/http/middleware"
+	"order_service/domain"
+	"platform/problem"
+	"platform/rabbitmq"
+)
+
+const (
+	defaultDeadLetterLimit = 20
+	maxDeadLetterLimit     = 100
+)
+
+type DeadLetterStore interface {
+	DeadLetters(queue string, limit int) ([]*rabbitmq.DeadLetter, error)
+	ReplayDeadLetters(queue string, limit int) (int, error)
+}
+
+// DeadLetterHandler lets admins look at and replay messages that consumers
+// gave up on.
+type DeadLetterHandler struct {
+	store DeadLetterStore
+}
+
+func NewDeadLetterHandler(store DeadLetterStore) *DeadLetterHandler {
+	return &DeadLetterHandler{store: store}
+}
+
+func (h *DeadLetterHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	letters, err := h.store.DeadLetters(r.PathValue("queue"), limit)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(letters)
+}
+
+func (h *DeadLetterHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	limit, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	replayed, err := h.store.ReplayDeadLetters(r.PathValue("queue"), limit)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"replayed": replayed})
+}
+
+// authorize restricts the endpoints to admins and reads ?limit=.
+func (h *DeadLetterHandler) authorize(w http.ResponseWriter, r *http.Request) (int, bool) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, domain.ErrUnauthorized)
+		return 0, false
+	}
+	if !actor.IsAdmin() {
+		problem.Error(w, r, domain.ErrForbidden)
+		return 0, false
+	}
+
+	limit := defaultDeadLetterLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			problem.Write(w, r, http.StatusBadRequest, "invalid limit")
+			return 0, false
+		}
+		limit = min(n, maxDeadLetterLimit)
+	}
+
+	return limit, true
+}
@@ -1,10 +1,16 @@
 package handler
 
 import (
+	"platform/problem"
+
 	"encoding/json"
 	"net/http"
+	"net/url"
+	"order_service/delivery/http/middleware"
 	"order_service/domain"
 	"order_service/usecase"
+	"strconv"
+	"time"
 )
 
 type OrderHandler struct {
@@ -16,20 +22,61 @@ func NewOrderHandler(uc usecase.OrderUseCase) *OrderHandler {
 }
 
 type CreateOrderRequest struct {
+	// UserID is optional and only accepted from admins ordering on behalf
+	// of another user; it defaults to the authenticated caller.
 	UserID int64              `json:"user_id"`
 	Items  []domain.OrderItem `json:"items"`
 }
 
 func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, domain.ErrUnauthorized)
+		return
+	}
+
 	var req CreateOrderRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid request", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	order, err := h.uc.CreateOrder(r.Context(), actor, req.UserID, req.Items)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+type CancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *OrderHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid order id")
+		return
+	}
+
+	var req CancelOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid request")
 		return
 	}
 
-	order, err := h.uc.CreateOrder(req.UserID, req.Items)
+	order, err := h.uc.RequestCancellation(r.Context(), actor, id, req.Reason)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -37,3 +84,111 @@ func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(order)
 }
 
+func (h *OrderHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid order id")
+		return
+	}
+
+	order, err := h.uc.GetOrder(r.Context(), actor, id)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *OrderHandler) ListByUser(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	filter, err := parseOrderFilter(r.URL.Query())
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	page, err := h.uc.ListUserOrders(r.Context(), actor, userID, filter, r.URL.Query().Get("cursor"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *OrderHandler) List(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	filter, err := parseOrderFilter(r.URL.Query())
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	page, err := h.uc.ListOrders(r.Context(), actor, filter, r.URL.Query().Get("cursor"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// parseOrderFilter reads ?status=&from=&to=&limit= . Dates accept RFC 3339
+// or a plain YYYY-MM-DD; "to" is exclusive.
+func parseOrderFilter(q url.Values) (domain.OrderFilter, error) {
+	var filter domain.OrderFilter
+	var err error
+
+	filter.Status = domain.OrderStatus(q.Get("status"))
+
+	if filter.From, err = parseTime(q.Get("from")); err != nil {
+		return filter, domain.Invalid("invalid from")
+	}
+	if filter.To, err = parseTime(q.Get("to")); err != nil {
+		return filter, domain.Invalid("invalid to")
+	}
+
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			return filter, domain.Invalid("invalid limit")
+		}
+	}
+
+	return filter, nil
+}
+
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, v)
+}
@@ -3,11 +3,28 @@ package routes
 import (
 	"net/http"
 	"order_service/delivery/http/handler"
+	"order_service/metrics"
 )
 
-func SetupOrderRoutes(h *handler.OrderHandler) *http.ServeMux {
+func SetupOrderRoutes(
+	h *handler.OrderHandler,
+	outboxHandler *handler.OutboxHandler,
+	deadLetterHandler *handler.DeadLetterHandler,
+	healthHandler *handler.HealthHandler,
+	authenticate func(http.Handler) http.Handler,
+) *http.ServeMux {
 	mux := http.NewServeMux()
-	mux.HandleFunc("POST /orders", h.Create)
+	mux.Handle("POST /orders", authenticate(http.HandlerFunc(h.Create)))
+	mux.Handle("GET /orders", authenticate(http.HandlerFunc(h.List)))
+	mux.Handle("GET /orders/{id}", authenticate(http.HandlerFunc(h.GetByID)))
+	mux.Handle("POST /orders/{id}/cancel", authenticate(http.HandlerFunc(h.Cancel)))
+	mux.Handle("GET /users/{id}/orders", authenticate(http.HandlerFunc(h.ListByUser)))
+	mux.HandleFunc("GET /outbox/stats", outboxHandler.Stats)
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("GET /healthz", healthHandler.Live)
+	mux.HandleFunc("GET /readyz", healthHandler.Ready)
+	mux.Handle("GET /admin/dlq/{queue}", authenticate(http.HandlerFunc(deadLetterHandler.List)))
+	mux.Handle("POST /admin/dlq/{queue}/replay", authenticate(http.HandlerFunc(deadLetterHandler.Replay)))
 	return mux
 }
 
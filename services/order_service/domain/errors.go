@@ -0,0 +1,28 @@
+package domain
+
+import "platform/domainerr"
+
+// Kinds of failure a caller can act on. They are shared by every service;
+// see domainerr.
+var (
+	ErrInvalid      = domainerr.ErrInvalid
+	ErrUnauthorized = domainerr.ErrUnauthorized
+	ErrForbidden    = domainerr.ErrForbidden
+	ErrNotFound     = domainerr.ErrNotFound
+	ErrConflict     = domainerr.ErrConflict
+)
+
+var (
+	ErrOrderNotFound  = &Error{Kind: ErrNotFound, Msg: "order not found"}
+	ErrUnknownUser    = &Error{Kind: ErrInvalid, Msg: "user not registered in order service"}
+	ErrUnknownProduct = &Error{Kind: ErrInvalid, Msg: "unknown product"}
+	ErrInvalidCursor  = &Error{Kind: ErrInvalid, Msg: "invalid cursor"}
+)
+
+// Error is a domain error whose message is safe to show to the caller.
+type Error = domainerr.Error
+
+// Invalid reports input the caller has to fix.
+func Invalid(msg string) error {
+	return domainerr.Invalid(msg)
+}
@@ -4,17 +4,12 @@ import "time"
 
 type OrderStatus string
 
-const (
-	StatusPendingInventory OrderStatus = "PENDING_INVENTORY"
-	StatusConfirmed        OrderStatus = "CONFIRMED"
-	StatusCancelled        OrderStatus = "CANCELLED"
-)
-
 type Order struct {
 	ID        int64        `json:"id"`
 	UserID    int64        `json:"user_id"`
 	Status    OrderStatus `json:"status"`
 	Items     []OrderItem `json:"items"`
+	Total     float64     `json:"total"`
 	CreatedAt time.Time   `json:"created_at"`
 }
 
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, id := range []int64{1, 42, 1 << 40} {
+		got, err := DecodeCursor(EncodeCursor(id))
+		if err != nil || got != id {
+			t.Errorf("DecodeCursor(EncodeCursor(%d)) = %d, %v", id, got, err)
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+		want   int64
+		err    error
+	}{
+		{"first page", "", 0, nil},
+		{"not base64", "***", 0, ErrInvalidCursor},
+		{"not a number", encode("abc"), 0, ErrInvalidCursor},
+		{"zero", encode("0"), 0, ErrInvalidCursor},
+		{"negative", encode("-5"), 0, ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Errorf("got %d, %v, want %d, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
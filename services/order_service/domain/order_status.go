@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	StatusPendingInventory OrderStatus = "PENDING_INVENTORY"
+	StatusConfirmed        OrderStatus = "CONFIRMED"
+	StatusPaid             OrderStatus = "PAID"
+	StatusShipped          OrderStatus = "SHIPPED"
+	StatusDelivered        OrderStatus = "DELIVERED"
+	StatusCancelled        OrderStatus = "CANCELLED"
+	StatusRefunded         OrderStatus = "REFUNDED"
+)
+
+var (
+	ErrInvalidTransition = &Error{Kind: ErrConflict, Msg: "invalid order status transition"}
+	// ErrStatusConflict means the order changed status between reading it
+	// and updating it.
+	ErrStatusConflict = &Error{Kind: ErrConflict, Msg: "order status changed concurrently"}
+)
+
+// orderTransitions is the order lifecycle:
+//
+//	PENDING_INVENTORY → CONFIRMED → PAID → SHIPPED → DELIVERED
+//	PENDING_INVENTORY, CONFIRMED → CANCELLED
+//	PAID, DELIVERED → REFUNDED
+//
+// CANCELLED and REFUNDED are terminal.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	StatusPendingInventory: {StatusConfirmed, StatusCancelled},
+	StatusConfirmed:        {StatusPaid, StatusCancelled},
+	StatusPaid:             {StatusShipped, StatusRefunded},
+	StatusShipped:          {StatusDelivered},
+	StatusDelivered:        {StatusRefunded},
+	StatusCancelled:        {},
+	StatusRefunded:         {},
+}
+
+func (s OrderStatus) IsValid() bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+func (s OrderStatus) IsTerminal() bool {
+	return s.IsValid() && len(orderTransitions[s]) == 0
+}
+
+func (s OrderStatus) IsCancellable() bool {
+	return s.CanTransitionTo(StatusCancelled)
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionError is returned for a move the lifecycle does not allow. It
+// matches ErrInvalidTransition with errors.Is.
+type TransitionError struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot move order from %s to %s", e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// ValidateTransition checks a status change against the lifecycle.
+func ValidateTransition(from, to OrderStatus) error {
+	if !from.CanTransitionTo(to) {
+		return &TransitionError{From: from, To: to}
+	}
+	return nil
+}
+
+// StatusChange is one applied transition, stored in order_status_history.
+// ChangedBy is 0 for changes made by the system (e.g. inventory events).
+type StatusChange struct {
+	OrderID   int64       `json:"order_id"`
+	From      OrderStatus `json:"from"`
+	To        OrderStatus `json:"to"`
+	Reason    string      `json:"reason,omitempty"`
+	ChangedBy int64       `json:"changed_by,omitempty"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+var allStatuses = []OrderStatus{
+	StatusPendingInventory,
+	StatusConfirmed,
+	StatusPaid,
+	StatusShipped,
+	StatusDelivered,
+	StatusCancelled,
+	StatusRefunded,
+}
+
+func TestValidateTransition(t *testing.T) {
+	allowed := map[OrderStatus][]OrderStatus{
+		StatusPendingInventory: {StatusConfirmed, StatusCancelled},
+		StatusConfirmed:        {StatusPaid, StatusCancelled},
+		StatusPaid:             {StatusShipped, StatusRefunded},
+		StatusShipped:          {StatusDelivered},
+		StatusDelivered:        {StatusRefunded},
+	}
+
+	for _, from := range append(allStatuses, "UNKNOWN") {
+		for _, to := range append(allStatuses, "UNKNOWN") {
+			want := false
+			for _, next := range allowed[from] {
+				if next == to {
+					want = true
+				}
+			}
+
+			err := ValidateTransition(from, to)
+			if want {
+				if err != nil {
+					t.Errorf("%s → %s: unexpected error %v", from, to, err)
+				}
+				continue
+			}
+
+			var transitionErr *TransitionError
+			if !errors.As(err, &transitionErr) || transitionErr.From != from || transitionErr.To != to {
+				t.Errorf("%s → %s: got %v, want a TransitionError", from, to, err)
+			}
+			if !errors.Is(err, ErrInvalidTransition) || !errors.Is(err, ErrConflict) {
+				t.Errorf("%s → %s: %v does not match ErrInvalidTransition and ErrConflict", from, to, err)
+			}
+		}
+	}
+}
+
+func TestOrderStatusPredicates(t *testing.T) {
+	tests := []struct {
+		status      OrderStatus
+		valid       bool
+		terminal    bool
+		cancellable bool
+	}{
+		{StatusPendingInventory, true, false, true},
+		{StatusConfirmed, true, false, true},
+		{StatusPaid, true, false, false},
+		{StatusShipped, true, false, false},
+		{StatusDelivered, true, false, false},
+		{StatusCancelled, true, true, false},
+		{StatusRefunded, true, true, false},
+		{"UNKNOWN", false, false, false},
+		{"", false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.IsValid(); got != tt.valid {
+				t.Errorf("IsValid() = %v, want %v", got, tt.valid)
+			}
+			if got := tt.status.IsTerminal(); got != tt.terminal {
+				t.Errorf("IsTerminal() = %v, want %v", got, tt.terminal)
+			}
+			if got := tt.status.IsCancellable(); got != tt.cancellable {
+				t.Errorf("IsCancellable() = %v, want %v", got, tt.cancellable)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// ProductPrice is order_service's read model of product_service's catalog,
+// kept up to date from product.created and product.price_changed.
+type ProductPrice struct {
+	ProductID int64     `json:"product_id"`
+	Name      string    `json:"name"`
+	Price     float64   `json:"price"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,5 @@
+package domain
+
+// ServiceName identifies this service in its logs, traces and metrics and
+// as the producer of the events it publishes.
+const ServiceName = "order_service"
@@ -1,77 +1,130 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
 	"net/http"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"order_service/auth"
 	"order_service/config"
 	"order_service/delivery/http/handler"
+	"order_service/delivery/http/middleware"
 	"order_service/delivery/http/routes"
+	"order_service/domain"
 	"order_service/messaging"
+	"order_service/metrics"
+	"order_service/migrations"
 	"order_service/repository"
 	"order_service/usecase"
-
-	"github.com/streadway/amqp"
+	"platform/health"
+	"platform/httpmw"
+	"platform/logging"
+	"platform/postgres"
+	"platform/rabbitmq"
+	"platform/tracing"
 )
 
+// shutdownTimeout bounds draining requests, messages and the outbox relay
+// on SIGTERM. It stays below the grace period Docker Compose allows before
+// it kills the container.
+const shutdownTimeout = 20 * time.Second
+
+// requestTimeout is the deadline of every HTTP request.
+const requestTimeout = 10 * time.Second
+
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// -------------------------
-	// Database
+	// Config
 	// -------------------------
-	db, err := config.ConnectDB()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatal("invalid config", logging.Err(err))
+	}
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			logging.Fatal("failed to print config", logging.Err(err))
+		}
+		return
+	}
+	if len(cfg.Args) > 0 && cfg.Args[0] != "migrate" {
+		logging.Fatal("unknown command", "command", cfg.Args[0])
 	}
-	defer db.Close()
 
 	// -------------------------
-	// RabbitMQ
+	// Logging
 	// -------------------------
-	rabbitURL := os.Getenv("RABBITMQ_URL")
-	if rabbitURL == "" {
-		log.Fatal("RABBITMQ_URL is not set")
-	}
-	if !strings.HasPrefix(rabbitURL, "amqp://") && !strings.HasPrefix(rabbitURL, "amqps://") {
-		log.Fatal("invalid RABBITMQ_URL: " + rabbitURL)
+	if err := logging.Setup(cfg.Log.Level, domain.ServiceName); err != nil {
+		logging.Fatal("invalid LOG_LEVEL", logging.Err(err))
 	}
 
-	conn, err := amqp.Dial(rabbitURL)
+	// -------------------------
+	// Tracing
+	// -------------------------
+	shutdownTracing, err := tracing.Setup(context.Background(), domain.ServiceName)
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatal("failed to set up tracing", logging.Err(err))
 	}
-	defer conn.Close()
+	defer shutdownTracing(context.Background())
 
-	ch, err := conn.Channel()
+	// -------------------------
+	// Database
+	// -------------------------
+	db, err := postgres.Connect(ctx, &cfg.DB)
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatal("failed to connect to database", logging.Err(err))
 	}
-	defer ch.Close()
-
-	err = ch.ExchangeDeclare(
-		"events",
-		"topic",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+	defer db.Close()
+
+	// -------------------------
+	// Migrations
+	// -------------------------
+	if len(cfg.Args) > 0 {
+		if err := runMigrate(ctx, db, cfg.Args[1:]); err != nil {
+			logging.Fatal("migrate failed", logging.Err(err))
+		}
+		return
+	}
+	if cfg.DB.MigrateOnStart {
+		if _, err := migrations.Migrator.Up(ctx, db); err != nil {
+			logging.Fatal("failed to migrate database", logging.Err(err))
+		}
+	}
+
+	// -------------------------
+	// RabbitMQ
+	// -------------------------
+	// The connection redials on its own if the broker goes away.
+	conn, err := rabbitmq.Dial(cfg.RabbitMQ.URL, cfg.RabbitMQ.Exchange)
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatal("failed to connect to rabbitmq", logging.Err(err))
 	}
+	defer conn.Close()
 
 	// -------------------------
 	// Repositories
 	// -------------------------
 	orderRepo := repository.NewPostgresRepository(db)
 	userViewRepo := repository.NewUserViewPostgres(db)
+	productViewRepo := repository.NewProductViewPostgres(db)
+
+	transactor := repository.NewTransactor(db)
 
 	// -------------------------
 	// Publisher
 	// -------------------------
-	publisher := messaging.NewRabbitPublisher(ch)
+	publisher := messaging.NewRabbitPublisher(conn)
 
 	// -------------------------
 	// UseCases
@@ -79,35 +132,109 @@ func main() {
 	orderUC := usecase.NewOrderUseCase(
 		orderRepo,
 		userViewRepo,
-		publisher,
+		productViewRepo,
+		transactor,
 	)
 
+	// -------------------------
+	// Outbox relay
+	// -------------------------
+	relay := messaging.NewOutboxRelay(transactor, repository.NewOutboxPostgres(db), publisher, cfg.RabbitMQ.Exchange)
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(relayCtx)
+	}()
+
 	// -------------------------
 	// Rabbit Consumers
 	// -------------------------
+	runner := rabbitmq.NewConsumerRunner(conn)
 
 	// user.registered → insert into user_view
-	if err := messaging.ConsumeUserRegistered(ch, userViewRepo); err != nil {
-		log.Fatal(err)
-	}
+	messaging.ConsumeUserRegistered(runner, cfg.RabbitMQ.Queues.UserRegistered, transactor)
+
+	// product.created / product.price_changed → product_view
+	messaging.ConsumeProductCreated(runner, cfg.RabbitMQ.Queues.ProductCreated, transactor)
+	messaging.ConsumeProductPriceChanged(runner, cfg.RabbitMQ.Queues.ProductPriceChanged, transactor)
 
 	// inventory.reserved → CONFIRMED
-	if err := messaging.ConsumeInventoryReserved(ch, orderRepo); err != nil {
-		log.Fatal(err)
-	}
+	messaging.ConsumeInventoryReserved(runner, cfg.RabbitMQ.Queues.InventoryReserved, orderUC)
 
 	// inventory.failed → CANCELLED
-	if err := messaging.ConsumeInventoryFailed(ch, orderRepo); err != nil {
-		log.Fatal(err)
+	messaging.ConsumeInventoryFailed(runner, cfg.RabbitMQ.Queues.InventoryFailed, orderUC)
+
+	if err := runner.Start(); err != nil {
+		logging.Fatal("failed to start consumers", logging.Err(err))
 	}
 
+	// -------------------------
+	// Metrics
+	// -------------------------
+	metrics.RegisterDB(db)
+	metrics.RegisterOutbox(relay.Stats)
+	metrics.RegisterQueues(ctx, runner.QueueDepths)
+	metrics.RegisterOrders(ctx, orderRepo.CountByStatus)
+
 	// -------------------------
 	// HTTP
 	// -------------------------
+	verifier := auth.NewVerifier(cfg.Auth.JWKSURL, cfg.Auth.Issuer)
+
 	orderHandler := handler.NewOrderHandler(orderUC)
-	router := routes.SetupOrderRoutes(orderHandler)
+	outboxHandler := handler.NewOutboxHandler(relay)
+	deadLetterHandler := handler.NewDeadLetterHandler(runner)
+	healthHandler := handler.NewHealthHandler(
+		health.Checks{"consumers": runner.Live},
+		health.Checks{
+			"postgres":  db.PingContext,
+			"rabbitmq":  conn.Check,
+			"consumers": runner.Alive,
+		},
+	)
+	router := routes.SetupOrderRoutes(orderHandler, outboxHandler, deadLetterHandler, healthHandler, middleware.Authenticate(verifier))
+
+	httpHandler := middleware.Timeout(requestTimeout)(
+		httpmw.Trace(httpmw.AccessLog(middleware.Metrics(router))),
+	)
+	server := &http.Server{Addr: cfg.HTTP.Addr, Handler: httpHandler}
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Order Service running", "addr", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err := <-serverErr:
+		logging.Fatal("http server stopped", logging.Err(err))
+	}
+
+	// -------------------------
+	// Shutdown
+	// -------------------------
+	// Requests first, then messages, then the relay, so the events they
+	// enqueue still go out. Deferred calls then close the RabbitMQ
+	// connection and the database and flush traces.
+	stop() // a second signal kills the process right away
+	slog.Info("shutting down", "timeout", shutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("http requests not drained", logging.Err(err))
+	}
+	if err := runner.Stop(shutdownCtx); err != nil {
+		slog.Error("messages not drained", logging.Err(err))
+	}
+	stopRelay()
+	select {
+	case <-relayDone:
+	case <-shutdownCtx.Done():
+		slog.Error("outbox relay not stopped", logging.Err(shutdownCtx.Err()))
+	}
 
-	log.Println("Order Service running on :8081")
-	log.Fatal(http.ListenAndServe(":8081", router))
+	slog.Info("Order Service stopped")
 }
 
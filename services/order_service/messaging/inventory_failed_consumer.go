@@ -1,73 +1,30 @@
 package messaging
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"fmt"
 
+	"platform/rabbitmq"
+
+	"events"
 	"github.com/streadway/amqp"
-	"order_service/domain"
-	"order_service/repository"
+	"order_service/usecase"
 )
 
 func ConsumeInventoryFailed(
-	ch *amqp.Channel,
-	orderRepo repository.OrderRepository,
-) error {
-
-	q, err := ch.QueueDeclare(
-		"inventory_failed_queue",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		return err
-	}
-
-	err = ch.QueueBind(
-		q.Name,
-		"inventory.failed",
-		"events",
-		false,
-		nil,
-	)
-	if err != nil {
-		return err
-	}
-
-	msgs, err := ch.Consume(
-		q.Name,
-		"",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		return err
-	}
-
-	go func() {
-		for msg := range msgs {
-			var event InventoryFailedEvent
-			if err := json.Unmarshal(msg.Body, &event); err != nil {
-				log.Println("invalid inventory.failed:", err)
-				continue
-			}
-
-			err := orderRepo.UpdateStatus(
-				event.OrderID,
-				domain.StatusCancelled,
-			)
-			if err != nil {
-				log.Println("failed to cancel order:", err)
-			}
+	runner *rabbitmq.ConsumerRunner,
+	queue string,
+	orderUC usecase.OrderUseCase,
+) {
+	runner.Register(queue, events.InventoryFailed, func(ctx context.Context, msg amqp.Delivery) error {
+		var event events.InventoryFailedV1
+		if err := decodeEvent(msg, 1, &event); err != nil {
+			return err
 		}
-	}()
 
-	return nil
+		if err := orderUC.CancelOrder(ctx, msg.MessageId, event.OrderID, event.Reason); err != nil {
+			return fmt.Errorf("failed to cancel order: %w", err)
+		}
+		return nil
+	})
 }
-
@@ -0,0 +1,63 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"order_service/repository"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	r := &OutboxRelay{baseBackoff: time.Second, maxBackoff: 5 * time.Minute}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{4, 16 * time.Second},
+		{8, 256 * time.Second},
+		{9, 5 * time.Minute},
+		{1000, 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := r.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// deleteStub hands out the given batch sizes from DeleteSent.
+type deleteStub struct {
+	repository.OutboxRepository
+	batches []int64
+	calls   int
+}
+
+func (s *deleteStub) DeleteSent(_ context.Context, olderThan time.Duration, limit int) (int64, error) {
+	if olderThan != retention || limit != purgeBatchSize {
+		return 0, errors.New("unexpected arguments")
+	}
+	s.calls++
+	if len(s.batches) == 0 {
+		return 0, nil
+	}
+	n := s.batches[0]
+	s.batches = s.batches[1:]
+	return n, nil
+}
+
+func TestOutboxPurgeStopsAtShortBatch(t *testing.T) {
+	outbox := &deleteStub{batches: []int64{purgeBatchSize, purgeBatchSize, 3, purgeBatchSize}}
+	r := &OutboxRelay{outbox: outbox}
+
+	r.purge(context.Background())
+
+	if outbox.calls != 3 {
+		t.Errorf("DeleteSent called %d times, want 3", outbox.calls)
+	}
+}
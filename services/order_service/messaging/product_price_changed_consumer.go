@@ -0,0 +1,42 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+
+	"platform/rabbitmq"
+
+	"events"
+	"github.com/streadway/amqp"
+	"order_service/domain"
+	"order_service/repository"
+)
+
+func ConsumeProductPriceChanged(
+	runner *rabbitmq.ConsumerRunner,
+	queue string,
+	transactor repository.Transactor,
+) {
+	runner.Register(queue, events.ProductPriceChanged, func(ctx context.Context, msg amqp.Delivery) error {
+		var event events.ProductPriceChangedV1
+		if err := decodeEvent(msg, 1, &event); err != nil {
+			return err
+		}
+
+		err := transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+			first, err := tx.FirstDelivery(ctx, "product.price_changed", msg.MessageId)
+			if err != nil || !first {
+				return err
+			}
+			return tx.ProductViews.Upsert(ctx, &domain.ProductPrice{
+				ProductID: event.ProductID,
+				Price:     event.Price,
+				UpdatedAt: event.OccurredAt,
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update product_view price: %w", err)
+		}
+		return nil
+	})
+}
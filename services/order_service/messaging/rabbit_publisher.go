@@ -1,25 +1,164 @@
 package messaging
 
-import "github.com/streadway/amqp"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
+	"platform/rabbitmq"
+	"platform/tracing"
+
+	"github.com/streadway/amqp"
+)
+
+const confirmTimeout = 5 * time.Second
+
+var (
+	ErrPublishNacked  = errors.New("message was nacked by the broker")
+	ErrPublishTimeout = errors.New("timed out waiting for publisher confirm")
+)
+
+// ReturnedError is reported when the broker could not route a mandatory
+// message to any queue.
+type ReturnedError struct {
+	RoutingKey string
+	ReplyCode  uint16
+	ReplyText  string
+}
+
+func (e *ReturnedError) Error() string {
+	return fmt.Sprintf("message to %q was returned: %d %s", e.RoutingKey, e.ReplyCode, e.ReplyText)
+}
+
+// RabbitPublisher publishes persistent, mandatory messages to the events
+// exchange on a channel in confirm mode. Publish blocks until the broker has
+// confirmed the message, so a nil error means it is stored in at least one
+// queue. The channel is reopened on the next publish after it has been
+// closed, e.g. because the connection was re-established.
 type RabbitPublisher struct {
-	ch *amqp.Channel
+	conn *rabbitmq.Connection
+
+	mu       sync.Mutex
+	ch       *amqp.Channel
+	closed   chan *amqp.Error
+	confirms chan amqp.Confirmation
+	returns  chan amqp.Return
 }
 
-func NewRabbitPublisher(ch *amqp.Channel) *RabbitPublisher {
-	return &RabbitPublisher{ch: ch}
+func NewRabbitPublisher(conn *rabbitmq.Connection) *RabbitPublisher {
+	return &RabbitPublisher{conn: conn}
 }
 
-func (p *RabbitPublisher) Publish(eventName string, body []byte) error {
-	return p.ch.Publish(
-		"events",   // exchange
-		eventName, // routing key
-		false,
+func (p *RabbitPublisher) Publish(ctx context.Context, eventName, messageID string, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ch, err := p.channel()
+	if err != nil {
+		return err
+	}
+
+	err = ch.Publish(
+		p.conn.Exchange(), // exchange
+		eventName,         // routing key
+		true,              // mandatory: unroutable messages come back as returns
 		false,
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		},
+		newPublishing(ctx, messageID, body),
 	)
+	if err != nil {
+		p.reset()
+		return err
+	}
+
+	return p.awaitConfirm(ctx)
 }
 
+// newPublishing wraps body as a persistent message carrying the correlation
+// id and trace context of ctx.
+func newPublishing(ctx context.Context, messageID string, body []byte) amqp.Publishing {
+	return amqp.Publishing{
+		ContentType:   "application/json",
+		DeliveryMode:  amqp.Persistent,
+		MessageId:     messageID,
+		CorrelationId: tracing.CorrelationID(ctx),
+		Headers:       tracing.Inject(ctx).Table(),
+		Body:          body,
+	}
+}
+
+// awaitConfirm waits for the outcome of the single message in flight. The
+// broker sends basic.return before the ack of an unroutable message, so a
+// pending return is checked once the confirm is in. Giving up early, when
+// ctx ends or the confirm takes too long, resets the channel.
+func (p *RabbitPublisher) awaitConfirm(ctx context.Context) error {
+	select {
+	case confirm, ok := <-p.confirms:
+		if !ok {
+			p.reset()
+			return rabbitmq.ErrConnectionClosed
+		}
+
+		select {
+		case ret := <-p.returns:
+			return &ReturnedError{
+				RoutingKey: ret.RoutingKey,
+				ReplyCode:  ret.ReplyCode,
+				ReplyText:  ret.ReplyText,
+			}
+		default:
+		}
+
+		if !confirm.Ack {
+			return ErrPublishNacked
+		}
+		return nil
+
+	case <-time.After(confirmTimeout):
+		// A late confirm would be mistaken for the next message's, so
+		// start over on a new channel.
+		p.reset()
+		return ErrPublishTimeout
+
+	case <-ctx.Done():
+		p.reset()
+		return ctx.Err()
+	}
+}
+
+// channel must be called with p.mu held.
+func (p *RabbitPublisher) channel() (*amqp.Channel, error) {
+	if p.ch != nil {
+		select {
+		case <-p.closed:
+			p.ch = nil
+		default:
+			return p.ch, nil
+		}
+	}
+
+	ch, err := p.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	p.ch = ch
+	p.closed = ch.NotifyClose(make(chan *amqp.Error, 1))
+	p.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+	p.returns = ch.NotifyReturn(make(chan amqp.Return, 1))
+	return ch, nil
+}
+
+// reset must be called with p.mu held.
+func (p *RabbitPublisher) reset() {
+	if p.ch != nil {
+		p.ch.Close()
+		p.ch = nil
+	}
+}
@@ -0,0 +1,222 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"events"
+	"order_service/domain"
+	"order_service/repository"
+	"platform/httpmw"
+	"platform/tracing"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestTracePropagatesToPublish follows one request through the HTTP
+// middleware into the outbox and out of the relay, and checks that the
+// publish span continues the request's trace and that the correlation id
+// reaches the AMQP message.
+func TestTracePropagatesToPublish(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider(domain.ServiceName, exporter)
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+		provider.Shutdown(context.Background())
+	})
+
+	// Request: a handler enqueues an event through the real outbox
+	// repository, whose INSERT is captured instead of sent to Postgres.
+	db := &insertRecorder{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orders", func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.Start(r.Context(), "OrderUseCase.CreateOrder")
+		defer span.End()
+
+		event, err := events.New(domain.ServiceName, events.OrderCreated, events.OrderCreatedV1{
+			OrderID:   1,
+			UserID:    7,
+			Items:     []events.OrderItemV1{{ProductID: 3, Quantity: 2}},
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			t.Errorf("events.New: %v", err)
+			return
+		}
+		if err := repository.NewOutboxPostgres(db).Add(ctx, event); err != nil {
+			t.Errorf("outbox Add: %v", err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set(tracing.RequestIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+	httpmw.Trace(mux).ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+
+	// Relay: the row comes back the way FetchDue would read it.
+	outbox := &memoryOutbox{messages: []*domain.OutboxMessage{db.message(t)}}
+	publisher := &recordingPublisher{}
+	relay := NewOutboxRelay(memoryTransactor{outbox}, outbox, publisher, "events")
+	if _, err := relay.relayBatch(context.Background()); err != nil {
+		t.Fatalf("relayBatch: %v", err)
+	}
+	if len(outbox.sent) != 1 || len(publisher.sent) != 1 {
+		t.Fatalf("sent %d rows, published %d messages, want 1 each", len(outbox.sent), len(publisher.sent))
+	}
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("ForceFlush: %v", err)
+	}
+	spans := map[string]tracetest.SpanStub{}
+	for _, s := range exporter.GetSpans() {
+		spans[s.Name] = s
+	}
+	server := mustSpan(t, spans, "POST /orders")
+	usecase := mustSpan(t, spans, "OrderUseCase.CreateOrder")
+	add := mustSpan(t, spans, "OutboxRepository.Add")
+	publish := mustSpan(t, spans, "publish order.created")
+
+	if server.Parent.IsValid() {
+		t.Errorf("server span has parent %s, want a new trace", server.Parent.SpanID())
+	}
+	for _, link := range []struct{ child, parent tracetest.SpanStub }{
+		{usecase, server},
+		{add, usecase},
+		// The outbox records the caller's span, not its own.
+		{publish, usecase},
+	} {
+		if link.child.Parent.SpanID() != link.parent.SpanContext.SpanID() {
+			t.Errorf("%s: parent %s, want %s (%s)", link.child.Name, link.child.Parent.SpanID(), link.parent.SpanContext.SpanID(), link.parent.Name)
+		}
+		if link.child.SpanContext.TraceID() != server.SpanContext.TraceID() {
+			t.Errorf("%s: trace %s, want %s", link.child.Name, link.child.SpanContext.TraceID(), server.SpanContext.TraceID())
+		}
+	}
+
+	msg := publisher.sent[0]
+	if msg.CorrelationId != "req-42" {
+		t.Errorf("CorrelationId = %q, want req-42", msg.CorrelationId)
+	}
+	if got := msg.Headers[tracing.CorrelationIDHeader]; got != "req-42" {
+		t.Errorf("%s header = %v, want req-42", tracing.CorrelationIDHeader, got)
+	}
+	traceparent, _ := msg.Headers["traceparent"].(string)
+	if !strings.Contains(traceparent, publish.SpanContext.TraceID().String()+"-"+publish.SpanContext.SpanID().String()) {
+		t.Errorf("traceparent header = %q, want trace %s and span %s", traceparent, publish.SpanContext.TraceID(), publish.SpanContext.SpanID())
+	}
+
+	env, err := events.Decode(events.OrderCreated, msg.MessageId, msg.Body)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if env.CorrelationID != "req-42" {
+		t.Errorf("envelope correlation_id = %q, want req-42", env.CorrelationID)
+	}
+}
+
+func mustSpan(t *testing.T, spans map[string]tracetest.SpanStub, name string) tracetest.SpanStub {
+	t.Helper()
+	s, ok := spans[name]
+	if !ok {
+		names := make([]string, 0, len(spans))
+		for n := range spans {
+			names = append(names, n)
+		}
+		t.Fatalf("no span %q, got %v", name, names)
+	}
+	return s
+}
+
+// insertRecorder is a repository.DBTX that remembers the arguments of the
+// last statement executed.
+type insertRecorder struct {
+	args []interface{}
+}
+
+func (db *insertRecorder) ExecContext(_ context.Context, _ string, args ...interface{}) (sql.Result, error) {
+	db.args = args
+	return nil, nil
+}
+
+func (db *insertRecorder) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (db *insertRecorder) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+// message turns the captured outbox INSERT back into the row FetchDue reads.
+func (db *insertRecorder) message(t *testing.T) *domain.OutboxMessage {
+	t.Helper()
+	if len(db.args) != 4 {
+		t.Fatalf("outbox insert had %d arguments, want 4", len(db.args))
+	}
+
+	m := &domain.OutboxMessage{
+		ID:         1,
+		MessageID:  db.args[0].(string),
+		RoutingKey: db.args[1].(string),
+		Payload:    db.args[2].([]byte),
+	}
+	if err := json.Unmarshal(db.args[3].([]byte), &m.Headers); err != nil {
+		t.Fatalf("outbox headers: %v", err)
+	}
+	return m
+}
+
+type memoryOutbox struct {
+	repository.OutboxRepository
+	messages []*domain.OutboxMessage
+	sent     []int64
+}
+
+func (o *memoryOutbox) FetchDue(context.Context, int) ([]*domain.OutboxMessage, error) {
+	return o.messages, nil
+}
+
+func (o *memoryOutbox) MarkSent(_ context.Context, id int64) error {
+	o.sent = append(o.sent, id)
+	return nil
+}
+
+func (o *memoryOutbox) MarkFailed(_ context.Context, id int64, reason string, _ time.Duration) error {
+	return errors.New("publish failed: " + reason)
+}
+
+type memoryTransactor struct {
+	outbox repository.OutboxRepository
+}
+
+func (m memoryTransactor) WithinTx(_ context.Context, fn func(tx *repository.Tx) error) error {
+	return fn(&repository.Tx{Outbox: m.outbox})
+}
+
+// recordingPublisher keeps the AMQP message RabbitPublisher would send.
+type recordingPublisher struct {
+	sent []amqp.Publishing
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, _, messageID string, payload []byte) error {
+	p.sent = append(p.sent, newPublishing(ctx, messageID, payload))
+	return nil
+}
@@ -1,75 +1,41 @@
 package messaging
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 
+	"platform/logging"
+	"platform/rabbitmq"
+
+	"events"
 	"github.com/streadway/amqp"
 	"order_service/repository"
 )
 
-type UserRegisteredEvent struct {
-	UserID int64 `json:"user_id"`
-}
-
 func ConsumeUserRegistered(
-	ch *amqp.Channel,
-	userViewRepo *repository.UserViewPostgres,
-) error {
-
-	q, err := ch.QueueDeclare(
-		"user_registered_queue",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		return err
-	}
-
-	err = ch.QueueBind(
-		q.Name,
-		"user.registered",
-		"events",
-		false,
-		nil,
-	)
-	if err != nil {
-		return err
-	}
-
-	msgs, err := ch.Consume(
-		q.Name,
-		"",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		return err
-	}
-
-	go func() {
-		for msg := range msgs {
-			var event UserRegisteredEvent
-			if err := json.Unmarshal(msg.Body, &event); err != nil {
-				log.Println("invalid user.registered event:", err)
-				continue
-			}
+	runner *rabbitmq.ConsumerRunner,
+	queue string,
+	transactor repository.Transactor,
+) {
+	runner.Register(queue, events.UserRegistered, func(ctx context.Context, msg amqp.Delivery) error {
+		var event events.UserRegisteredV1
+		if err := decodeEvent(msg, 1, &event); err != nil {
+			return err
+		}
 
-			if err := userViewRepo.Insert(event.UserID); err != nil {
-				log.Println("failed to insert into user_view:", err)
-				continue
+		err := transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+			first, err := tx.FirstDelivery(ctx, "user.registered", msg.MessageId)
+			if err != nil || !first {
+				return err
 			}
-
-			log.Println("user_view inserted for user_id:", event.UserID)
+			return tx.UserViews.Insert(ctx, event.UserID)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert into user_view: %w", err)
 		}
-	}()
 
-	return nil
+		slog.InfoContext(ctx, "user_view inserted", logging.UserID(event.UserID))
+		return nil
+	})
 }
-
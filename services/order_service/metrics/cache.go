@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"platform/logging"
+)
+
+// refreshInterval is how often gauges that would be expensive to compute on
+// every scrape, such as queue depths and per-status counts, are refreshed
+// in the background. Scrapes serve the last result.
+const refreshInterval = 15 * time.Second
+
+// cached holds the last result of a background refresh. Until the first
+// one is done it holds the zero value, so a collector has nothing to report.
+type cached[T any] struct {
+	mu    sync.Mutex
+	value T
+	err   error
+}
+
+func (c *cached[T]) get() (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value, c.err
+}
+
+func (c *cached[T]) set(value T, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value, c.err = value, err
+}
+
+// refresh calls load right away and then every refreshInterval until ctx
+// ends, storing each result in c.
+func refresh[T any](ctx context.Context, c *cached[T], load func(ctx context.Context) (T, error)) {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		loadCtx, cancel := context.WithTimeout(ctx, scrapeTimeout)
+		value, err := load(loadCtx)
+		cancel()
+		if err != nil {
+			slog.WarnContext(ctx, "failed to refresh metrics", logging.Err(err))
+		}
+		c.set(value, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
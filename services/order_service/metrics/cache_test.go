@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRefreshStoresResult(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	c := &cached[int]{}
+
+	// Cancelling during the first load makes refresh return right after
+	// storing it.
+	refresh(ctx, c, func(context.Context) (int, error) {
+		calls++
+		cancel()
+		return 42, nil
+	})
+
+	if calls != 1 {
+		t.Errorf("load called %d times, want 1", calls)
+	}
+	if v, err := c.get(); v != 42 || err != nil {
+		t.Errorf("get() = %d, %v, want 42, nil", v, err)
+	}
+}
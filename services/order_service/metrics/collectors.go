@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"order_service/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeTimeout bounds the queries a scrape or a background refresh runs
+// against the database and the broker.
+const scrapeTimeout = 5 * time.Second
+
+// RegisterOutbox exposes the outbox backlog as reported by stats, which is
+// called on every scrape.
+func RegisterOutbox(stats func(ctx context.Context) (*domain.OutboxStats, error)) {
+	Registry.MustRegister(&outboxCollector{stats: stats})
+}
+
+// RegisterQueues exposes the number of ready messages in every queue
+// reported by depths, which is called in the background every
+// refreshInterval until ctx ends. Growing consumer queues mean consumers are
+// falling behind; growing dead-letter queues need someone to look at them.
+func RegisterQueues(ctx context.Context, depths func() (map[string]int, error)) {
+	c := &queueCollector{}
+	go refresh(ctx, &c.depths, func(context.Context) (map[string]int, error) {
+		return depths()
+	})
+	Registry.MustRegister(c)
+}
+
+var (
+	outboxPendingDesc = prometheus.NewDesc(
+		"outbox_pending_messages",
+		"Outbox messages not yet published.",
+		nil, nil,
+	)
+	outboxLagDesc = prometheus.NewDesc(
+		"outbox_lag_seconds",
+		"Age of the oldest unpublished outbox message.",
+		nil, nil,
+	)
+	queueMessagesDesc = prometheus.NewDesc(
+		"rabbitmq_queue_messages",
+		"Messages ready for delivery in a queue this service consumes from, including its dead-letter queue.",
+		[]string{"queue"}, nil,
+	)
+)
+
+type outboxCollector struct {
+	stats func(ctx context.Context) (*domain.OutboxStats, error)
+}
+
+func (c *outboxCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- outboxPendingDesc
+	ch <- outboxLagDesc
+}
+
+func (c *outboxCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	stats, err := c.stats(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(outboxPendingDesc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(outboxPendingDesc, prometheus.GaugeValue, float64(stats.Pending))
+	ch <- prometheus.MustNewConstMetric(outboxLagDesc, prometheus.GaugeValue, stats.LagSeconds)
+}
+
+type queueCollector struct {
+	depths cached[map[string]int]
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueMessagesDesc
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	depths, err := c.depths.get()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(queueMessagesDesc, err)
+		return
+	}
+
+	for queue, n := range depths {
+		ch <- prometheus.MustNewConstMetric(queueMessagesDesc, prometheus.GaugeValue, float64(n), queue)
+	}
+}
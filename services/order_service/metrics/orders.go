@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"context"
+
+	"order_service/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OrderTransitions counts committed status changes, including the initial
+// PENDING_INVENTORY of a new order (from="").
+var OrderTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "order_status_transitions_total",
+	Help: "Order status changes by previous and new status.",
+}, []string{"from", "to"})
+
+var ordersDesc = prometheus.NewDesc(
+	"orders",
+	"Orders currently in each status.",
+	[]string{"status"}, nil,
+)
+
+func init() {
+	Registry.MustRegister(OrderTransitions)
+}
+
+// RegisterOrders exposes the number of orders per status as counted by
+// count, which is called in the background every refreshInterval until ctx
+// ends.
+func RegisterOrders(ctx context.Context, count func(ctx context.Context) (map[domain.OrderStatus]int64, error)) {
+	c := &ordersCollector{}
+	go refresh(ctx, &c.counts, count)
+	Registry.MustRegister(c)
+}
+
+type ordersCollector struct {
+	counts cached[map[domain.OrderStatus]int64]
+}
+
+func (c *ordersCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ordersDesc
+}
+
+func (c *ordersCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.counts.get()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(ordersDesc, err)
+		return
+	}
+
+	for status, n := range counts {
+		ch <- prometheus.MustNewConstMetric(ordersDesc, prometheus.GaugeValue, float64(n), string(status))
+	}
+}
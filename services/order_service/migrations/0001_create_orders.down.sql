@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_view;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
@@ -1,8 +1,3 @@
--- =========================
--- Order Service Schema
--- =========================
-
--- 1️⃣ Orders table (Order header)
 CREATE TABLE IF NOT EXISTS orders (
     id SERIAL PRIMARY KEY,
     user_id BIGINT NOT NULL,
@@ -10,7 +5,6 @@ CREATE TABLE IF NOT EXISTS orders (
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
--- 2️⃣ Order Items table (Order lines)
 CREATE TABLE IF NOT EXISTS order_items (
     id SERIAL PRIMARY KEY,
     order_id BIGINT NOT NULL,
@@ -24,19 +18,14 @@ CREATE TABLE IF NOT EXISTS order_items (
         ON DELETE CASCADE
 );
 
--- 3️⃣ User View table (Read model from events)
+-- Read model of user.registered
 CREATE TABLE IF NOT EXISTS user_view (
     user_id BIGINT PRIMARY KEY,
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
--- =========================
--- Indexes (Performance)
--- =========================
-
 CREATE INDEX IF NOT EXISTS idx_orders_user_id
     ON orders(user_id);
 
 CREATE INDEX IF NOT EXISTS idx_order_items_order_id
     ON order_items(order_id);
-
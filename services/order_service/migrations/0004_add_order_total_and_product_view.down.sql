@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS product_view;
+ALTER TABLE orders DROP COLUMN IF EXISTS total;
@@ -0,0 +1,9 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS total NUMERIC(12,2);
+
+-- Price list built from product.created and product.price_changed
+CREATE TABLE IF NOT EXISTS product_view (
+    product_id BIGINT PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    price NUMERIC(10,2) NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
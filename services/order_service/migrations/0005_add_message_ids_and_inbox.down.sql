@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS inbox;
+ALTER TABLE outbox DROP COLUMN IF EXISTS message_id;
@@ -0,0 +1,10 @@
+ALTER TABLE outbox
+    ADD COLUMN IF NOT EXISTS message_id UUID NOT NULL UNIQUE DEFAULT gen_random_uuid();
+
+-- Events already handled by a consumer
+CREATE TABLE IF NOT EXISTS inbox (
+    consumer TEXT NOT NULL,
+    message_id TEXT NOT NULL,
+    processed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (consumer, message_id)
+);
@@ -0,0 +1,2 @@
+-- Trace context and correlation id of the request that enqueued the event
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS headers JSONB;
@@ -0,0 +1,4 @@
+-- Lets the relay find sent rows past their retention without a full scan
+CREATE INDEX IF NOT EXISTS idx_outbox_sent
+    ON outbox(sent_at)
+    WHERE sent_at IS NOT NULL;
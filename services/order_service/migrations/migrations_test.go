@@ -0,0 +1,22 @@
+package migrations
+
+import "testing"
+
+func TestAllIsOrderedAndComplete(t *testing.T) {
+	all, err := Migrator.All()
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if len(all) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	for i, m := range all {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d_%s: versions must run 1, 2, … without gaps", m.Version, m.Name)
+		}
+		if m.Up == "" || m.Down == "" {
+			t.Errorf("migration %d_%s is missing its up or down script", m.Version, m.Name)
+		}
+	}
+}
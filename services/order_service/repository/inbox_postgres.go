@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"platform/tracing"
+)
+
+type inboxPostgres struct {
+	db DBTX
+}
+
+func NewInboxPostgres(db DBTX) InboxRepository {
+	return &inboxPostgres{db: db}
+}
+
+func (r *inboxPostgres) MarkProcessed(ctx context.Context, consumer, messageID string) (bool, error) {
+	_, span := tracing.Start(ctx, "InboxRepository.MarkProcessed")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO inbox (consumer, message_id)
+		 VALUES ($1, $2)
+		 ON CONFLICT (consumer, message_id) DO NOTHING`,
+		consumer,
+		messageID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record processed message: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
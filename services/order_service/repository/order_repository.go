@@ -1,11 +1,24 @@
 package repository
 
-import "order_service/domain"
+import (
+	"context"
+	"order_service/domain"
+)
 
 type OrderRepository interface {
-	Create(order *domain.Order) error
-	GetByID(id int64) (*domain.Order, error)
-	UpdateStatus(orderID int64, status domain.OrderStatus) error
-
+	// Create stores order and its first status history entry, recording
+	// actor as the one who placed it; an admin may order on behalf of
+	// order.UserID.
+	Create(ctx context.Context, actor domain.Actor, order *domain.Order) error
+	GetByID(ctx context.Context, id int64) (*domain.Order, error)
+	// List returns orders matching the filter, newest first, with their items.
+	List(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error)
+	GetStatus(ctx context.Context, orderID int64) (domain.OrderStatus, error)
+	// ChangeStatus moves the order from change.From to change.To only if it
+	// is still in change.From, and records the change in the history. It
+	// returns ErrStatusConflict when the order was modified in between.
+	ChangeStatus(ctx context.Context, change *domain.StatusChange) error
+	// CountByStatus returns how many orders are in each status; statuses
+	// without orders are left out.
+	CountByStatus(ctx context.Context) (map[domain.OrderStatus]int64, error)
 }
-
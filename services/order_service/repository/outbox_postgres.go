@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"events"
+	"fmt"
+	"order_service/domain"
+	"platform/tracing"
+	"time"
+)
+
+type outboxPostgres struct {
+	db DBTX
+}
+
+func NewOutboxPostgres(db DBTX) OutboxRepository {
+	return &outboxPostgres{db: db}
+}
+
+// Add stores event together with the correlation id and trace context of
+// ctx, so the relay can publish it as part of the same trace later on.
+func (r *outboxPostgres) Add(ctx context.Context, event *events.Envelope) error {
+	_, span := tracing.Start(ctx, "OutboxRepository.Add")
+	defer span.End()
+
+	if event.CorrelationID == "" {
+		event.CorrelationID = tracing.CorrelationID(ctx)
+	}
+
+	payload, err := event.Marshal()
+	if err != nil {
+		return err
+	}
+
+	headers, err := json.Marshal(tracing.Inject(ctx))
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO outbox (message_id, routing_key, payload, headers)
+		 VALUES ($1, $2, $3, $4)`,
+		event.ID,
+		event.Type,
+		payload,
+		headers,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s: %w", event.Type, err)
+	}
+	return nil
+}
+
+func (r *outboxPostgres) FetchDue(ctx context.Context, limit int) ([]*domain.OutboxMessage, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, message_id, routing_key, payload, COALESCE(headers, '{}'), attempts, COALESCE(last_error, ''), next_attempt_at, created_at
+		 FROM outbox
+		 WHERE sent_at IS NULL AND next_attempt_at <= NOW()
+		 ORDER BY id
+		 LIMIT $1
+		 FOR UPDATE SKIP LOCKED`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*domain.OutboxMessage
+	for rows.Next() {
+		var m domain.OutboxMessage
+		var headers []byte
+		if err := rows.Scan(
+			&m.ID,
+			&m.MessageID,
+			&m.RoutingKey,
+			&m.Payload,
+			&headers,
+			&m.Attempts,
+			&m.LastError,
+			&m.NextAttemptAt,
+			&m.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(headers, &m.Headers); err != nil {
+			return nil, fmt.Errorf("invalid headers on outbox message %d: %w", m.ID, err)
+		}
+		messages = append(messages, &m)
+	}
+
+	return messages, rows.Err()
+}
+
+func (r *outboxPostgres) MarkSent(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE outbox SET sent_at = NOW(), attempts = attempts + 1 WHERE id = $1`,
+		id,
+	)
+	return err
+}
+
+func (r *outboxPostgres) MarkFailed(ctx context.Context, id int64, reason string, retryIn time.Duration) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE outbox
+		 SET attempts = attempts + 1,
+		     last_error = $1,
+		     next_attempt_at = NOW() + make_interval(secs => $2)
+		 WHERE id = $3`,
+		reason,
+		retryIn.Seconds(),
+		id,
+	)
+	return err
+}
+
+func (r *outboxPostgres) DeleteSent(ctx context.Context, olderThan time.Duration, limit int) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM outbox
+		 WHERE id IN (
+			SELECT id FROM outbox
+			WHERE sent_at < NOW() - make_interval(secs => $1)
+			ORDER BY sent_at
+			LIMIT $2
+		 )`,
+		olderThan.Seconds(),
+		limit,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sent outbox messages: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// Stats only reads unsent rows, which idx_outbox_unsent covers, so its cost
+// follows the backlog rather than the size of the table.
+func (r *outboxPostgres) Stats(ctx context.Context) (*domain.OutboxStats, error) {
+	var stats domain.OutboxStats
+	var oldest sql.NullTime
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT
+			COUNT(*),
+			MIN(created_at),
+			COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(created_at)), 0),
+			COALESCE(MAX(attempts), 0)
+		 FROM outbox
+		 WHERE sent_at IS NULL`,
+	).Scan(&stats.Pending, &oldest, &stats.LagSeconds, &stats.MaxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox stats: %w", err)
+	}
+
+	if oldest.Valid {
+		stats.OldestPendingAt = &oldest.Time
+	}
+
+	return &stats, nil
+}
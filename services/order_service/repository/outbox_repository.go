@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"events"
+	"order_service/domain"
+	"time"
+)
+
+type OutboxRepository interface {
+	Add(ctx context.Context, event *events.Envelope) error
+	// FetchDue locks up to limit unsent messages whose retry time has come.
+	// It must run inside a transaction so concurrent relays skip them.
+	FetchDue(ctx context.Context, limit int) ([]*domain.OutboxMessage, error)
+	MarkSent(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, reason string, retryIn time.Duration) error
+	// DeleteSent deletes up to limit messages sent more than olderThan ago
+	// and reports how many it deleted.
+	DeleteSent(ctx context.Context, olderThan time.Duration, limit int) (int64, error)
+	// Stats describes the unsent messages.
+	Stats(ctx context.Context) (*domain.OutboxStats, error)
+}
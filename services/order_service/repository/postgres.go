@@ -1,31 +1,42 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"order_service/domain"
+	"platform/tracing"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 type postgresRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewPostgresRepository(db *sql.DB) OrderRepository {
+func NewPostgresRepository(db DBTX) OrderRepository {
 	return &postgresRepository{db: db}
 }
 
-func (r *postgresRepository) Create(order *domain.Order) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+func (r *postgresRepository) Create(ctx context.Context, actor domain.Actor, order *domain.Order) error {
+	_, span := tracing.Start(ctx, "OrderRepository.Create")
+	defer span.End()
+
+	return runInTx(ctx, r.db, func(tx DBTX) error {
+		return r.create(ctx, tx, actor, order)
+	})
+}
 
-	err = tx.QueryRow(
-		`INSERT INTO orders (user_id, status, created_at)
-		 VALUES ($1, $2, $3)
+func (r *postgresRepository) create(ctx context.Context, tx DBTX, actor domain.Actor, order *domain.Order) error {
+	err := tx.QueryRowContext(ctx,
+		`INSERT INTO orders (user_id, status, total, created_at)
+		 VALUES ($1, $2, $3, $4)
 		 RETURNING id`,
 		order.UserID,
 		order.Status,
+		order.Total,
 		order.CreatedAt,
 	).Scan(&order.ID)
 
@@ -33,9 +44,8 @@ func (r *postgresRepository) Create(order *domain.Order) error {
 		return err
 	}
 
-	
 	for _, item := range order.Items {
-		_, err := tx.Exec(
+		_, err := tx.ExecContext(ctx,
 			`INSERT INTO order_items (order_id, product_id, quantity, price)
 			 VALUES ($1, $2, $3, $4)`,
 			order.ID,
@@ -47,35 +57,229 @@ func (r *postgresRepository) Create(order *domain.Order) error {
 			return err
 		}
 	}
-	return tx.Commit()
+
+	var changedBy sql.NullInt64
+	if actor.UserID > 0 {
+		changedBy = sql.NullInt64{Int64: actor.UserID, Valid: true}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO order_status_history (order_id, from_status, to_status, reason, changed_by, changed_at)
+		 VALUES ($1, NULL, $2, 'order created', $3, $4)`,
+		order.ID,
+		order.Status,
+		changedBy,
+		order.CreatedAt,
+	)
+	return err
 }
 
+func (r *postgresRepository) GetByID(ctx context.Context, id int64) (*domain.Order, error) {
+	_, span := tracing.Start(ctx, "OrderRepository.GetByID")
+	defer span.End()
 
-func (r *postgresRepository) GetByID(id int64) (*domain.Order, error) {
-	row := r.db.QueryRow(
-		`SELECT id, user_id, status, created_at FROM orders WHERE id=$1`,
+	row := r.db.QueryRowContext(ctx,
+		`SELECT id, user_id, status, COALESCE(total, 0), created_at FROM orders WHERE id=$1`,
 		id,
 	)
 
 	var o domain.Order
-	err := row.Scan(&o.ID, &o.UserID, &o.Status, &o.CreatedAt)
+	err := row.Scan(&o.ID, &o.UserID, &o.Status, &o.Total, &o.CreatedAt)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	if err := r.loadItems(ctx, []*domain.Order{&o}); err != nil {
 		return nil, err
 	}
 
 	return &o, nil
 }
 
+func (r *postgresRepository) List(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error) {
+	_, span := tracing.Start(ctx, "OrderRepository.List")
+	defer span.End()
 
-func (r *postgresRepository) UpdateStatus(
-	orderID int64,
-	status domain.OrderStatus,
-) error {
-	_, err := r.db.Exec(
-		`UPDATE orders SET status = $1 WHERE id = $2`,
-		status,
+	var (
+		conds []string
+		args  []interface{}
+	)
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.UserID > 0 {
+		add("user_id = $%d", filter.UserID)
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		add("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("created_at < $%d", filter.To)
+	}
+	if filter.AfterID > 0 {
+		add("id < $%d", filter.AfterID)
+	}
+
+	query := `SELECT id, user_id, status, COALESCE(total, 0), created_at FROM orders`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*domain.Order
+	for rows.Next() {
+		var o domain.Order
+		if err := rows.Scan(&o.ID, &o.UserID, &o.Status, &o.Total, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, &o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadItems(ctx, orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// loadItems fills Items for all given orders with a single query.
+func (r *postgresRepository) loadItems(ctx context.Context, orders []*domain.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*domain.Order, len(orders))
+	ids := make([]int64, 0, len(orders))
+	for _, o := range orders {
+		o.Items = []domain.OrderItem{}
+		byID[o.ID] = o
+		ids = append(ids, o.ID)
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT order_id, product_id, quantity, COALESCE(price, 0)
+		 FROM order_items
+		 WHERE order_id = ANY($1)
+		 ORDER BY id`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID int64
+		var item domain.OrderItem
+		if err := rows.Scan(&orderID, &item.ProductID, &item.Quantity, &item.Price); err != nil {
+			return err
+		}
+		if o, ok := byID[orderID]; ok {
+			o.Items = append(o.Items, item)
+		}
+	}
+	return rows.Err()
+}
+
+func (r *postgresRepository) GetStatus(ctx context.Context, orderID int64) (domain.OrderStatus, error) {
+	_, span := tracing.Start(ctx, "OrderRepository.GetStatus")
+	defer span.End()
+
+	var status domain.OrderStatus
+	err := r.db.QueryRowContext(ctx,
+		`SELECT status FROM orders WHERE id = $1`,
 		orderID,
+	).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", domain.ErrOrderNotFound
+	}
+	return status, err
+}
+
+func (r *postgresRepository) ChangeStatus(ctx context.Context, change *domain.StatusChange) error {
+	_, span := tracing.Start(ctx, "OrderRepository.ChangeStatus")
+	defer span.End()
+
+	return runInTx(ctx, r.db, func(tx DBTX) error {
+		return r.changeStatus(ctx, tx, change)
+	})
+}
+
+func (r *postgresRepository) changeStatus(ctx context.Context, tx DBTX, change *domain.StatusChange) error {
+	if change.ChangedAt.IsZero() {
+		change.ChangedAt = time.Now()
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE orders SET status = $1 WHERE id = $2 AND status = $3`,
+		change.To,
+		change.OrderID,
+		change.From,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return domain.ErrStatusConflict
+	}
+
+	var changedBy sql.NullInt64
+	if change.ChangedBy > 0 {
+		changedBy = sql.NullInt64{Int64: change.ChangedBy, Valid: true}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO order_status_history (order_id, from_status, to_status, reason, changed_by, changed_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		change.OrderID,
+		change.From,
+		change.To,
+		change.Reason,
+		changedBy,
+		change.ChangedAt,
 	)
 	return err
 }
 
+func (r *postgresRepository) CountByStatus(ctx context.Context) (map[domain.OrderStatus]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM orders GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count orders by status: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[domain.OrderStatus]int64)
+	for rows.Next() {
+		var status domain.OrderStatus
+		var n int64
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+
+	return counts, rows.Err()
+}
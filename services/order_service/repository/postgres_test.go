@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"order_service/domain"
+)
+
+// execStub is a DBTX whose UPDATEs report rowsAffected and which records
+// every statement it executes.
+type execStub struct {
+	rowsAffected int64
+	statements   []string
+}
+
+func (db *execStub) ExecContext(_ context.Context, query string, _ ...interface{}) (sql.Result, error) {
+	db.statements = append(db.statements, strings.Fields(query)[0])
+	return stubResult(db.rowsAffected), nil
+}
+
+func (db *execStub) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (db *execStub) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+type stubResult int64
+
+func (r stubResult) LastInsertId() (int64, error) { return 0, errors.New("not supported") }
+func (r stubResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func TestChangeStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		rowsAffected   int64
+		want           error
+		wantStatements []string
+	}{
+		{"applied", 1, nil, []string{"UPDATE", "INSERT"}},
+		{"order moved on", 0, domain.ErrStatusConflict, []string{"UPDATE"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &execStub{rowsAffected: tt.rowsAffected}
+			err := NewPostgresRepository(db).ChangeStatus(context.Background(), &domain.StatusChange{
+				OrderID: 1,
+				From:    domain.StatusPendingInventory,
+				To:      domain.StatusConfirmed,
+			})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if strings.Join(db.statements, ",") != strings.Join(tt.wantStatements, ",") {
+				t.Errorf("executed %v, want %v", db.statements, tt.wantStatements)
+			}
+		})
+	}
+}
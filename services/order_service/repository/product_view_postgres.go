@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"order_service/domain"
+	"platform/tracing"
+
+	"github.com/lib/pq"
+)
+
+type ProductViewPostgres struct {
+	db DBTX
+}
+
+func NewProductViewPostgres(db DBTX) *ProductViewPostgres {
+	return &ProductViewPostgres{db: db}
+}
+
+// Upsert stores the product unless a newer version is already known, so
+// events that arrive out of order cannot roll a price back.
+func (r *ProductViewPostgres) Upsert(ctx context.Context, product *domain.ProductPrice) error {
+	_, span := tracing.Start(ctx, "ProductViewRepository.Upsert")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO product_view (product_id, name, price, updated_at)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (product_id) DO UPDATE SET
+			name = CASE WHEN EXCLUDED.name <> '' THEN EXCLUDED.name ELSE product_view.name END,
+			price = EXCLUDED.price,
+			updated_at = EXCLUDED.updated_at
+		 WHERE product_view.updated_at <= EXCLUDED.updated_at`,
+		product.ProductID,
+		product.Name,
+		product.Price,
+		product.UpdatedAt,
+	)
+	return err
+}
+
+func (r *ProductViewPostgres) GetByIDs(ctx context.Context, productIDs []int64) (map[int64]*domain.ProductPrice, error) {
+	_, span := tracing.Start(ctx, "ProductViewRepository.GetByIDs")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT product_id, name, price, updated_at
+		 FROM product_view
+		 WHERE product_id = ANY($1)`,
+		pq.Array(productIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make(map[int64]*domain.ProductPrice, len(productIDs))
+	for rows.Next() {
+		var p domain.ProductPrice
+		if err := rows.Scan(&p.ProductID, &p.Name, &p.Price, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		products[p.ProductID] = &p
+	}
+	return products, rows.Err()
+}
@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+	"order_service/domain"
+)
+
+type ProductViewRepository interface {
+	Upsert(ctx context.Context, product *domain.ProductPrice) error
+	GetByIDs(ctx context.Context, productIDs []int64) (map[int64]*domain.ProductPrice, error)
+}
@@ -1,17 +1,23 @@
 package repository
 
-import "database/sql"
+import (
+	"context"
+	"platform/tracing"
+)
 
 type UserViewPostgres struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewUserViewPostgres(db *sql.DB) *UserViewPostgres {
+func NewUserViewPostgres(db DBTX) *UserViewPostgres {
 	return &UserViewPostgres{db: db}
 }
 
-func (r *UserViewPostgres) Insert(userID int64) error {
-	_, err := r.db.Exec(
+func (r *UserViewPostgres) Insert(ctx context.Context, userID int64) error {
+	_, span := tracing.Start(ctx, "UserViewRepository.Insert")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx,
 		`INSERT INTO user_view (user_id)
 		 VALUES ($1)
 		 ON CONFLICT (user_id) DO NOTHING`,
@@ -19,9 +25,12 @@ func (r *UserViewPostgres) Insert(userID int64) error {
 	)
 	return err
 }
-func (r *UserViewPostgres) Exists(userID int64) (bool, error) {
+func (r *UserViewPostgres) Exists(ctx context.Context, userID int64) (bool, error) {
+	_, span := tracing.Start(ctx, "UserViewRepository.Exists")
+	defer span.End()
+
 	var exists bool
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		`SELECT EXISTS (SELECT 1 FROM user_view WHERE user_id = $1)`,
 		userID,
 	).Scan(&exists)
@@ -1,6 +1,8 @@
 package repository
 
+import "context"
+
 type UserViewRepository interface {
-	Exists(userID int64) (bool, error)
+	Insert(ctx context.Context, userID int64) error
+	Exists(ctx context.Context, userID int64) (bool, error)
 }
-
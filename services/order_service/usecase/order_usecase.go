@@ -1,80 +1,484 @@
 package usecase
 
 import (
-	"errors"
+	"platform/logging"
+
+	"context"
+	"events"
+	"fmt"
+	"log/slog"
+	"math"
 	"order_service/domain"
+	"order_service/metrics"
 	"order_service/repository"
+	"platform/tracing"
+	"strings"
 	"time"
-	"encoding/json"
 )
 
 type OrderUseCase interface {
-	CreateOrder(userID int64, items []domain.OrderItem) (*domain.Order, error)
+	CreateOrder(ctx context.Context, actor domain.Actor, userID int64, items []domain.OrderItem) (*domain.Order, error)
+	CancelOrder(ctx context.Context, messageID string, orderID int64, reason string) error
+	RequestCancellation(ctx context.Context, actor domain.Actor, orderID int64, reason string) (*domain.Order, error)
+	ConfirmOrder(ctx context.Context, messageID string, orderID int64) error
+
+	GetOrder(ctx context.Context, actor domain.Actor, orderID int64) (*domain.Order, error)
+	ListUserOrders(ctx context.Context, actor domain.Actor, userID int64, filter domain.OrderFilter, cursor string) (*domain.OrderPage, error)
+	ListOrders(ctx context.Context, actor domain.Actor, filter domain.OrderFilter, cursor string) (*domain.OrderPage, error)
 }
 
+const maxCancelReasonLength = 500
+
 type orderUseCase struct {
-	orderRepo repository.OrderRepository
-	userViewRepo repository.UserViewRepository
-	publisher    EventPublisher
+	orderRepo       repository.OrderRepository
+	userViewRepo    repository.UserViewRepository
+	productViewRepo repository.ProductViewRepository
+	transactor      repository.Transactor
 }
 
 func NewOrderUseCase(orderRepo repository.OrderRepository,
-		     userViewRepo repository.UserViewRepository,
-	             publisher EventPublisher,
-		) OrderUseCase {
-			return &orderUseCase{
-			orderRepo:    orderRepo,
-			userViewRepo: userViewRepo,
-			publisher:    publisher,
+	userViewRepo repository.UserViewRepository,
+	productViewRepo repository.ProductViewRepository,
+	transactor repository.Transactor,
+) OrderUseCase {
+	return &orderUseCase{
+		orderRepo:       orderRepo,
+		userViewRepo:    userViewRepo,
+		productViewRepo: productViewRepo,
+		transactor:      transactor,
 	}
 }
 
+// CreateOrder places an order for the authenticated actor. userID is only
+// honoured for admins placing an order on behalf of someone else; for
+// everyone else it must be empty or match the actor.
 func (uc *orderUseCase) CreateOrder(
+	ctx context.Context,
+	actor domain.Actor,
 	userID int64,
 	items []domain.OrderItem,
 ) (*domain.Order, error) {
+	ctx, span := tracing.Start(ctx, "OrderUseCase.CreateOrder")
+	defer span.End()
+
+	if userID == 0 {
+		userID = actor.UserID
+	}
+	if userID != actor.UserID && !actor.IsAdmin() {
+		return nil, domain.ErrForbidden
+	}
 
 	if userID <= 0 {
-		return nil, errors.New("invalid user id")
+		return nil, domain.Invalid("invalid user id")
 	}
 
-	exists, err := uc.userViewRepo.Exists(userID)
+	exists, err := uc.userViewRepo.Exists(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 	if !exists {
-		return nil, errors.New("user not registered in order service")
+		return nil, domain.ErrUnknownUser
 	}
 
 	if len(items) == 0 {
-		return nil, errors.New("order must have items")
+		return nil, domain.Invalid("order must have items")
+	}
+
+	priced, total, err := uc.priceItems(ctx, items)
+	if err != nil {
+		return nil, err
 	}
 
 	order := &domain.Order{
 		UserID:    userID,
 		Status:    domain.StatusPendingInventory,
-		Items:     items,
+		Items:     priced,
+		Total:     total,
 		CreatedAt: time.Now(),
 	}
 
-	if err := uc.orderRepo.Create(order); err != nil {
+	// The order and its order.created event commit together; the outbox
+	// relay publishes the event once the transaction is durable.
+	err = uc.transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+		if err := tx.Orders.Create(ctx, actor, order); err != nil {
+			return err
+		}
+
+		items := make([]events.OrderItemV1, 0, len(order.Items))
+		for _, item := range order.Items {
+			items = append(items, events.OrderItemV1{
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+				Price:     item.Price,
+			})
+		}
+
+		event, err := events.New(domain.ServiceName, events.OrderCreated, events.OrderCreatedV1{
+			OrderID:   order.ID,
+			UserID:    order.UserID,
+			Status:    string(order.Status),
+			Items:     items,
+			Total:     order.Total,
+			CreatedAt: order.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		return tx.Outbox.Add(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recordTransition("", order.Status)
+	slog.InfoContext(ctx, "order created",
+		logging.OrderID(order.ID),
+		logging.UserID(order.UserID),
+		slog.Int("items", len(order.Items)),
+		slog.Float64("total", order.Total),
+	)
+	return order, nil
+}
+
+// priceItems stamps every line with the current unit price from the product
+// read model, ignoring whatever price the client sent, and returns the order
+// total. Orders referencing products the catalog has never announced are
+// rejected.
+func (uc *orderUseCase) priceItems(ctx context.Context, items []domain.OrderItem) ([]domain.OrderItem, float64, error) {
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		if item.ProductID <= 0 {
+			return nil, 0, domain.Invalid("invalid product id")
+		}
+		if item.Quantity <= 0 {
+			return nil, 0, domain.Invalid(fmt.Sprintf("invalid quantity for product %d", item.ProductID))
+		}
+		ids = append(ids, item.ProductID)
+	}
+
+	products, err := uc.productViewRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	priced := make([]domain.OrderItem, 0, len(items))
+	var totalCents int64
+	for _, item := range items {
+		product, ok := products[item.ProductID]
+		if !ok {
+			return nil, 0, fmt.Errorf("%w: %d", domain.ErrUnknownProduct, item.ProductID)
+		}
+
+		item.Price = product.Price
+		priced = append(priced, item)
+		totalCents += int64(math.Round(product.Price*100)) * int64(item.Quantity)
+	}
+
+	return priced, float64(totalCents) / 100, nil
+}
+
+// CancelOrder handles inventory.failed: it marks the order CANCELLED and
+// announces it with order.cancelled so product_service can release any
+// reserved stock. Cancelling an already cancelled order, or seeing
+// messageID a second time, is a no-op.
+func (uc *orderUseCase) CancelOrder(ctx context.Context, messageID string, orderID int64, reason string) error {
+	ctx, span := tracing.Start(ctx, "OrderUseCase.CancelOrder")
+	defer span.End()
+
+	if orderID <= 0 {
+		return domain.Invalid("invalid order id")
+	}
+	ctx = logging.With(ctx, logging.OrderID(orderID))
+
+	change := &domain.StatusChange{
+		OrderID: orderID,
+		To:      domain.StatusCancelled,
+		Reason:  reason,
+	}
+	var changed bool
+	err := uc.transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+		first, err := tx.FirstDelivery(ctx, "inventory.failed", messageID)
+		if err != nil || !first {
+			return err
+		}
+
+		changed, err = changeStatus(ctx, tx, change)
+		if err != nil || !changed {
+			return err
+		}
+
+		return enqueueCancelled(ctx, tx, orderID, reason, 0)
+	})
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		recordTransition(change.From, change.To)
+		slog.InfoContext(ctx, "order cancelled", slog.String("reason", reason))
+	}
+	return nil
+}
+
+// RequestCancellation cancels an order on behalf of its owner or an admin.
+// Unlike CancelOrder it refuses orders that are no longer cancellable,
+// including ones that are already cancelled, and records who asked.
+func (uc *orderUseCase) RequestCancellation(
+	ctx context.Context,
+	actor domain.Actor,
+	orderID int64,
+	reason string,
+) (*domain.Order, error) {
+	ctx, span := tracing.Start(ctx, "OrderUseCase.RequestCancellation")
+	defer span.End()
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, domain.Invalid("cancellation reason is required")
+	}
+	if len(reason) > maxCancelReasonLength {
+		return nil, domain.Invalid(fmt.Sprintf("cancellation reason must be at most %d characters", maxCancelReasonLength))
+	}
+
+	order, err := uc.GetOrder(ctx, actor, orderID)
+	if err != nil {
 		return nil, err
 	}
 
-	if uc.publisher != nil {
-		event := map[string]interface{}{
-			"order_id":   order.ID,
-			"user_id":    order.UserID,
-			"status":     order.Status,
-			"items":      order.Items,
-			"created_at": order.CreatedAt,
+	if !order.Status.IsCancellable() {
+		return nil, &domain.TransitionError{From: order.Status, To: domain.StatusCancelled}
+	}
+
+	err = uc.transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+		_, err := changeStatus(ctx, tx, &domain.StatusChange{
+			OrderID:   orderID,
+			From:      order.Status,
+			To:        domain.StatusCancelled,
+			Reason:    reason,
+			ChangedBy: actor.UserID,
+		})
+		if err != nil {
+			return err
 		}
 
-		data, _ := json.Marshal(event)
-		_ = uc.publisher.Publish("order.created", data)
+		return enqueueCancelled(ctx, tx, orderID, reason, actor.UserID)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	recordTransition(order.Status, domain.StatusCancelled)
+	order.Status = domain.StatusCancelled
+	slog.InfoContext(ctx, "order cancelled",
+		logging.OrderID(orderID),
+		slog.String("reason", reason),
+		slog.Int64("cancelled_by", actor.UserID),
+	)
 	return order, nil
 }
 
+// ConfirmOrder moves a PENDING_INVENTORY order to CONFIRMED once its stock is
+// reserved. If the order was cancelled while the reservation was in flight,
+// order.cancelled is announced again so the late reservation gets released.
+// A redelivered inventory.reserved with the same messageID is ignored.
+func (uc *orderUseCase) ConfirmOrder(ctx context.Context, messageID string, orderID int64) error {
+	ctx, span := tracing.Start(ctx, "OrderUseCase.ConfirmOrder")
+	defer span.End()
 
+	if orderID <= 0 {
+		return domain.Invalid("invalid order id")
+	}
+	ctx = logging.With(ctx, logging.OrderID(orderID))
+
+	var outcome string
+	var confirmedFrom domain.OrderStatus
+	err := uc.transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+		first, err := tx.FirstDelivery(ctx, "inventory.reserved", messageID)
+		if err != nil || !first {
+			return err
+		}
+
+		status, err := tx.Orders.GetStatus(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if status == domain.StatusCancelled {
+			outcome = "inventory reserved for a cancelled order, releasing it"
+			return enqueueCancelled(ctx, tx, orderID, "inventory reserved after cancellation", 0)
+		}
+
+		changed, err := changeStatus(ctx, tx, &domain.StatusChange{
+			OrderID: orderID,
+			From:    status,
+			To:      domain.StatusConfirmed,
+			Reason:  "inventory reserved",
+		})
+		if changed {
+			outcome = "order confirmed"
+			confirmedFrom = status
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if confirmedFrom != "" {
+		recordTransition(confirmedFrom, domain.StatusConfirmed)
+	}
+	if outcome != "" {
+		slog.InfoContext(ctx, outcome)
+	}
+	return nil
+}
+
+// changeStatus validates change against the order lifecycle and applies it
+// with a compare-and-set on the current status. When change.From is empty
+// the current status is read first. Moving an order to the status it
+// already has reports changed=false without touching it, which keeps
+// redelivered events harmless.
+func changeStatus(ctx context.Context, tx *repository.Tx, change *domain.StatusChange) (bool, error) {
+	if change.From == "" {
+		current, err := tx.Orders.GetStatus(ctx, change.OrderID)
+		if err != nil {
+			return false, err
+		}
+		change.From = current
+	}
+
+	if change.From == change.To {
+		return false, nil
+	}
+
+	if err := domain.ValidateTransition(change.From, change.To); err != nil {
+		return false, err
+	}
+
+	if err := tx.Orders.ChangeStatus(ctx, change); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// recordTransition counts a committed status change; from is empty for a
+// new order.
+func recordTransition(from, to domain.OrderStatus) {
+	metrics.OrderTransitions.WithLabelValues(string(from), string(to)).Inc()
+}
+
+// enqueueCancelled writes order.cancelled to the outbox. cancelledBy is the
+// user who asked for it, or 0 when the system cancelled the order.
+func enqueueCancelled(ctx context.Context, tx *repository.Tx, orderID int64, reason string, cancelledBy int64) error {
+	event, err := events.New(domain.ServiceName, events.OrderCancelled, events.OrderCancelledV1{
+		OrderID:     orderID,
+		Reason:      reason,
+		CancelledAt: time.Now(),
+		CancelledBy: cancelledBy,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Outbox.Add(ctx, event)
+}
+
+// GetOrder returns an order with its items to its owner or an admin.
+func (uc *orderUseCase) GetOrder(ctx context.Context, actor domain.Actor, orderID int64) (*domain.Order, error) {
+	ctx, span := tracing.Start(ctx, "OrderUseCase.GetOrder")
+	defer span.End()
+
+	if orderID <= 0 {
+		return nil, domain.Invalid("invalid order id")
+	}
+
+	order, err := uc.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.UserID != actor.UserID && !actor.IsAdmin() {
+		return nil, domain.ErrForbidden
+	}
+
+	return order, nil
+}
+
+func (uc *orderUseCase) ListUserOrders(
+	ctx context.Context,
+	actor domain.Actor,
+	userID int64,
+	filter domain.OrderFilter,
+	cursor string,
+) (*domain.OrderPage, error) {
+	ctx, span := tracing.Start(ctx, "OrderUseCase.ListUserOrders")
+	defer span.End()
+
+	if userID <= 0 {
+		return nil, domain.Invalid("invalid user id")
+	}
+	if userID != actor.UserID && !actor.IsAdmin() {
+		return nil, domain.ErrForbidden
+	}
+
+	filter.UserID = userID
+	return uc.listOrders(ctx, filter, cursor)
+}
+
+// ListOrders lists orders across all users and is restricted to admins.
+func (uc *orderUseCase) ListOrders(
+	ctx context.Context,
+	actor domain.Actor,
+	filter domain.OrderFilter,
+	cursor string,
+) (*domain.OrderPage, error) {
+	ctx, span := tracing.Start(ctx, "OrderUseCase.ListOrders")
+	defer span.End()
+
+	if !actor.IsAdmin() {
+		return nil, domain.ErrForbidden
+	}
+
+	return uc.listOrders(ctx, filter, cursor)
+}
+
+func (uc *orderUseCase) listOrders(ctx context.Context, filter domain.OrderFilter, cursor string) (*domain.OrderPage, error) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, domain.Invalid("invalid status")
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, domain.Invalid("from must be before to")
+	}
+
+	afterID, err := domain.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	filter.AfterID = afterID
+
+	if filter.Limit <= 0 {
+		filter.Limit = domain.DefaultPageSize
+	}
+	if filter.Limit > domain.MaxPageSize {
+		filter.Limit = domain.MaxPageSize
+	}
+	pageSize := filter.Limit
+
+	// Fetch one extra row to know whether another page exists.
+	filter.Limit++
+	orders, err := uc.orderRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.OrderPage{Orders: orders}
+	if len(orders) > pageSize {
+		page.Orders = orders[:pageSize]
+		page.NextCursor = domain.EncodeCursor(page.Orders[pageSize-1].ID)
+	}
+	if page.Orders == nil {
+		page.Orders = []*domain.Order{}
+	}
+
+	return page, nil
+}
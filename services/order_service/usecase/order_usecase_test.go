@@ -0,0 +1,424 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"events"
+	"order_service/domain"
+	"order_service/repository"
+)
+
+// memoryOrders keeps order statuses in a map. When conflict is set,
+// ChangeStatus behaves as if another writer moved the order first.
+type memoryOrders struct {
+	repository.OrderRepository
+	orders   map[int64]*domain.Order
+	conflict bool
+	changes  []domain.StatusChange
+	// createdBy records the actor of every Create, by order id.
+	createdBy map[int64]domain.Actor
+}
+
+func (r *memoryOrders) Create(_ context.Context, actor domain.Actor, order *domain.Order) error {
+	order.ID = int64(len(r.orders) + 1)
+	r.orders[order.ID] = order
+	if r.createdBy == nil {
+		r.createdBy = map[int64]domain.Actor{}
+	}
+	r.createdBy[order.ID] = actor
+	return nil
+}
+
+func (r *memoryOrders) GetByID(_ context.Context, id int64) (*domain.Order, error) {
+	order, ok := r.orders[id]
+	if !ok {
+		return nil, domain.ErrOrderNotFound
+	}
+	copied := *order
+	return &copied, nil
+}
+
+func (r *memoryOrders) List(_ context.Context, filter domain.OrderFilter) ([]*domain.Order, error) {
+	var out []*domain.Order
+	for _, order := range r.orders {
+		if (filter.UserID == 0 || order.UserID == filter.UserID) &&
+			(filter.Status == "" || order.Status == filter.Status) &&
+			(filter.AfterID == 0 || order.ID < filter.AfterID) {
+			out = append(out, order)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	if len(out) > filter.Limit {
+		out = out[:filter.Limit]
+	}
+	return out, nil
+}
+
+func (r *memoryOrders) GetStatus(_ context.Context, id int64) (domain.OrderStatus, error) {
+	order, ok := r.orders[id]
+	if !ok {
+		return "", domain.ErrOrderNotFound
+	}
+	return order.Status, nil
+}
+
+func (r *memoryOrders) ChangeStatus(_ context.Context, change *domain.StatusChange) error {
+	order, ok := r.orders[change.OrderID]
+	if !ok || r.conflict || order.Status != change.From {
+		return domain.ErrStatusConflict
+	}
+	order.Status = change.To
+	r.changes = append(r.changes, *change)
+	return nil
+}
+
+type memoryInbox struct {
+	seen map[string]bool
+}
+
+func (i *memoryInbox) MarkProcessed(_ context.Context, consumer, messageID string) (bool, error) {
+	key := consumer + "/" + messageID
+	if i.seen[key] {
+		return false, nil
+	}
+	i.seen[key] = true
+	return true, nil
+}
+
+type memoryOutbox struct {
+	repository.OutboxRepository
+	events []*events.Envelope
+}
+
+func (o *memoryOutbox) Add(_ context.Context, event *events.Envelope) error {
+	o.events = append(o.events, event)
+	return nil
+}
+
+type memoryTransactor struct {
+	tx *repository.Tx
+}
+
+func (m memoryTransactor) WithinTx(_ context.Context, fn func(tx *repository.Tx) error) error {
+	return fn(m.tx)
+}
+
+type fixture struct {
+	orders *memoryOrders
+	outbox *memoryOutbox
+	uc     OrderUseCase
+}
+
+func newFixture(orders ...*domain.Order) *fixture {
+	f := &fixture{
+		orders: &memoryOrders{orders: map[int64]*domain.Order{}},
+		outbox: &memoryOutbox{},
+	}
+	for _, order := range orders {
+		f.orders.orders[order.ID] = order
+	}
+	tx := &repository.Tx{
+		Orders: f.orders,
+		Outbox: f.outbox,
+		Inbox:  &memoryInbox{seen: map[string]bool{}},
+	}
+	f.uc = NewOrderUseCase(f.orders, nil, nil, memoryTransactor{tx})
+	return f
+}
+
+// cancellations decodes the order.cancelled events in the outbox.
+func (f *fixture) cancellations(t *testing.T) []events.OrderCancelledV1 {
+	t.Helper()
+	var out []events.OrderCancelledV1
+	for _, env := range f.outbox.events {
+		if env.Type != events.OrderCancelled {
+			t.Fatalf("unexpected %s in outbox", env.Type)
+		}
+		var data events.OrderCancelledV1
+		if err := env.DataAs(1, &data); err != nil {
+			t.Fatalf("DataAs: %v", err)
+		}
+		out = append(out, data)
+	}
+	return out
+}
+
+func TestConfirmOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     domain.OrderStatus
+		conflict   bool
+		want       error
+		wantStatus domain.OrderStatus
+		wantChange bool
+	}{
+		{"pending is confirmed", domain.StatusPendingInventory, false, nil, domain.StatusConfirmed, true},
+		{"already confirmed", domain.StatusConfirmed, false, nil, domain.StatusConfirmed, false},
+		{"paid cannot go back", domain.StatusPaid, false, domain.ErrInvalidTransition, domain.StatusPaid, false},
+		{"status changed concurrently", domain.StatusPendingInventory, true, domain.ErrStatusConflict, domain.StatusPendingInventory, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(&domain.Order{ID: 1, UserID: 7, Status: tt.status})
+			f.orders.conflict = tt.conflict
+
+			err := f.uc.ConfirmOrder(context.Background(), "msg-1", 1)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if got := f.orders.orders[1].Status; got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+			if got := len(f.orders.changes) == 1; got != tt.wantChange {
+				t.Errorf("recorded changes %v, want a change: %v", f.orders.changes, tt.wantChange)
+			}
+			if len(f.outbox.events) != 0 {
+				t.Errorf("enqueued %d events, want none", len(f.outbox.events))
+			}
+		})
+	}
+}
+
+func TestConfirmOrderAfterCancellation(t *testing.T) {
+	f := newFixture(&domain.Order{ID: 1, UserID: 7, Status: domain.StatusCancelled})
+
+	for range 2 {
+		if err := f.uc.ConfirmOrder(context.Background(), "msg-1", 1); err != nil {
+			t.Fatalf("ConfirmOrder: %v", err)
+		}
+	}
+
+	if got := f.orders.orders[1].Status; got != domain.StatusCancelled {
+		t.Errorf("status = %s, want %s", got, domain.StatusCancelled)
+	}
+	if len(f.orders.changes) != 0 {
+		t.Errorf("recorded changes %v, want none", f.orders.changes)
+	}
+	// The redelivery must not release the reservation a second time.
+	cancelled := f.cancellations(t)
+	if len(cancelled) != 1 {
+		t.Fatalf("enqueued %d order.cancelled events, want 1", len(cancelled))
+	}
+	if cancelled[0].OrderID != 1 || cancelled[0].Reason != "inventory reserved after cancellation" || cancelled[0].CancelledBy != 0 {
+		t.Errorf("order.cancelled = %+v", cancelled[0])
+	}
+}
+
+func TestRequestCancellation(t *testing.T) {
+	owner := domain.Actor{UserID: 7, Role: domain.RoleClient}
+
+	tests := []struct {
+		name     string
+		status   domain.OrderStatus
+		actor    domain.Actor
+		conflict bool
+		want     error
+	}{
+		{"pending", domain.StatusPendingInventory, owner, false, nil},
+		{"confirmed", domain.StatusConfirmed, owner, false, nil},
+		{"paid", domain.StatusPaid, owner, false, domain.ErrInvalidTransition},
+		{"already cancelled", domain.StatusCancelled, owner, false, domain.ErrInvalidTransition},
+		{"someone else's order", domain.StatusPendingInventory, domain.Actor{UserID: 8, Role: domain.RoleClient}, false, domain.ErrForbidden},
+		{"status changed concurrently", domain.StatusPendingInventory, owner, true, domain.ErrStatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(&domain.Order{ID: 1, UserID: 7, Status: tt.status})
+			f.orders.conflict = tt.conflict
+
+			order, err := f.uc.RequestCancellation(context.Background(), tt.actor, 1, "changed my mind")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+
+			cancelled := f.cancellations(t)
+			if tt.want != nil {
+				if len(cancelled) != 0 {
+					t.Errorf("enqueued %d order.cancelled events, want none", len(cancelled))
+				}
+				return
+			}
+
+			if order.Status != domain.StatusCancelled {
+				t.Errorf("returned status %s, want %s", order.Status, domain.StatusCancelled)
+			}
+			if len(cancelled) != 1 || cancelled[0].CancelledBy != tt.actor.UserID {
+				t.Errorf("order.cancelled = %+v, want one cancelled by %d", cancelled, tt.actor.UserID)
+			}
+		})
+	}
+}
+
+func TestListUserOrdersPages(t *testing.T) {
+	f := newFixture(
+		&domain.Order{ID: 1, UserID: 7, Status: domain.StatusPendingInventory},
+		&domain.Order{ID: 2, UserID: 7, Status: domain.StatusCancelled},
+		&domain.Order{ID: 3, UserID: 8, Status: domain.StatusPendingInventory},
+		&domain.Order{ID: 4, UserID: 7, Status: domain.StatusPendingInventory},
+		&domain.Order{ID: 5, UserID: 7, Status: domain.StatusPendingInventory},
+	)
+	owner := domain.Actor{UserID: 7, Role: domain.RoleClient}
+
+	var got [][]int64
+	cursor := ""
+	for {
+		page, err := f.uc.ListUserOrders(context.Background(), owner, 7, domain.OrderFilter{Limit: 2}, cursor)
+		if err != nil {
+			t.Fatalf("ListUserOrders: %v", err)
+		}
+		var ids []int64
+		for _, order := range page.Orders {
+			ids = append(ids, order.ID)
+		}
+		got = append(got, ids)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	want := [][]int64{{5, 4}, {2, 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+}
+
+func TestListOrdersRejects(t *testing.T) {
+	f := newFixture()
+	owner := domain.Actor{UserID: 7, Role: domain.RoleClient}
+	admin := domain.Actor{UserID: 1, Role: domain.RoleAdmin}
+
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		list func() (*domain.OrderPage, error)
+		// want is the expected error, or nil for any validation error.
+		want error
+	}{
+		{"someone else's orders", func() (*domain.OrderPage, error) {
+			return f.uc.ListUserOrders(context.Background(), owner, 8, domain.OrderFilter{}, "")
+		}, domain.ErrForbidden},
+		{"all orders as a client", func() (*domain.OrderPage, error) {
+			return f.uc.ListOrders(context.Background(), owner, domain.OrderFilter{}, "")
+		}, domain.ErrForbidden},
+		{"forged cursor", func() (*domain.OrderPage, error) {
+			return f.uc.ListOrders(context.Background(), admin, domain.OrderFilter{}, "not-a-cursor")
+		}, domain.ErrInvalidCursor},
+		{"unknown status", func() (*domain.OrderPage, error) {
+			return f.uc.ListOrders(context.Background(), admin, domain.OrderFilter{Status: "LOST"}, "")
+		}, nil},
+		{"empty date range", func() (*domain.OrderPage, error) {
+			return f.uc.ListOrders(context.Background(), admin, domain.OrderFilter{From: now, To: now}, "")
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := tt.list()
+			if err == nil {
+				t.Fatalf("got page %+v, want an error", page)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+type memoryProducts struct {
+	repository.ProductViewRepository
+	prices map[int64]float64
+}
+
+func (r memoryProducts) GetByIDs(_ context.Context, ids []int64) (map[int64]*domain.ProductPrice, error) {
+	out := map[int64]*domain.ProductPrice{}
+	for _, id := range ids {
+		if price, ok := r.prices[id]; ok {
+			out[id] = &domain.ProductPrice{ProductID: id, Price: price}
+		}
+	}
+	return out, nil
+}
+
+func TestPriceItems(t *testing.T) {
+	uc := &orderUseCase{productViewRepo: memoryProducts{prices: map[int64]float64{1: 0.1, 2: 19.99}}}
+
+	// The client's prices are ignored.
+	items, total, err := uc.priceItems(context.Background(), []domain.OrderItem{
+		{ProductID: 1, Quantity: 3, Price: 0},
+		{ProductID: 2, Quantity: 2, Price: 0.01},
+	})
+	if err != nil {
+		t.Fatalf("priceItems: %v", err)
+	}
+	if items[0].Price != 0.1 || items[1].Price != 19.99 {
+		t.Errorf("prices = %v, %v, want 0.1, 19.99", items[0].Price, items[1].Price)
+	}
+	// Summed in cents, so 3 × 0.1 adds up to exactly 0.3.
+	if total != 40.28 {
+		t.Errorf("total = %v, want 40.28", total)
+	}
+
+	tests := []struct {
+		name  string
+		items []domain.OrderItem
+		want  error
+	}{
+		{"unknown product", []domain.OrderItem{{ProductID: 1, Quantity: 1}, {ProductID: 9, Quantity: 1}}, domain.ErrUnknownProduct},
+		{"invalid product id", []domain.OrderItem{{ProductID: 0, Quantity: 1}}, nil},
+		{"invalid quantity", []domain.OrderItem{{ProductID: 1, Quantity: 0}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := uc.priceItems(context.Background(), tt.items)
+			if err == nil {
+				t.Fatal("got nil, want an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+type memoryUsers struct {
+	repository.UserViewRepository
+	ids map[int64]bool
+}
+
+func (r memoryUsers) Exists(_ context.Context, id int64) (bool, error) {
+	return r.ids[id], nil
+}
+
+func TestCreateOrderRecordsActor(t *testing.T) {
+	orders := &memoryOrders{orders: map[int64]*domain.Order{}}
+	tx := &repository.Tx{Orders: orders, Outbox: &memoryOutbox{}}
+	uc := NewOrderUseCase(
+		orders,
+		memoryUsers{ids: map[int64]bool{7: true}},
+		memoryProducts{prices: map[int64]float64{1: 5}},
+		memoryTransactor{tx},
+	)
+
+	admin := domain.Actor{UserID: 1, Role: domain.RoleAdmin}
+	order, err := uc.CreateOrder(context.Background(), admin, 7, []domain.OrderItem{{ProductID: 1, Quantity: 1}})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+
+	if order.UserID != 7 {
+		t.Errorf("order placed for user %d, want 7", order.UserID)
+	}
+	if got := orders.createdBy[order.ID]; got != admin {
+		t.Errorf("created by %+v, want the admin %+v", got, admin)
+	}
+}
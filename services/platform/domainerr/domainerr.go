@@ -0,0 +1,35 @@
+// Package domainerr defines the kinds of failure every service reports, so
+// the HTTP layer maps them to status codes the same way everywhere.
+package domainerr
+
+// Kinds of failure a caller can act on. Every error the use cases return on
+// purpose matches exactly one of them with errors.Is, which is all the
+// delivery layer needs to pick a status code. Anything else is a fault of
+// the service itself.
+var (
+	ErrInvalid      = &Error{Msg: "invalid request"}
+	ErrUnauthorized = &Error{Msg: "unauthorized"}
+	ErrForbidden    = &Error{Msg: "forbidden"}
+	ErrNotFound     = &Error{Msg: "not found"}
+	ErrConflict     = &Error{Msg: "conflict"}
+)
+
+// Error is a domain error whose message is safe to show to the caller.
+type Error struct {
+	// Kind is one of the kinds above; it is nil for the kinds themselves.
+	Kind error
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Invalid reports input the caller has to fix.
+func Invalid(msg string) error {
+	return &Error{Kind: ErrInvalid, Msg: msg}
+}
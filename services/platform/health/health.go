@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// checkTimeout bounds each check, so one hung dependency cannot hold the
+// probe past the orchestrator's own timeout.
+const checkTimeout = 2 * time.Second
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check reports whether one dependency is usable; nil means it is.
+type Check func(ctx context.Context) error
+
+// Checks names the checks of one probe.
+type Checks map[string]Check
+
+// Result is the outcome of one check.
+type Result struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Report is the outcome of a probe. Status is up only when every check is.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Up reports whether every check passed.
+func (r *Report) Up() bool {
+	return r.Status == StatusUp
+}
+
+// Run runs every check concurrently, each with its own deadline.
+func (c Checks) Run(ctx context.Context) *Report {
+	report := &Report{Status: StatusUp, Checks: make(map[string]Result, len(c))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range c {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(ctx)
+			result := Result{Status: StatusUp, DurationMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if err != nil {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
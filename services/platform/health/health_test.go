@@ -0,0 +1,42 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+
+	report := Checks{"postgres": up}.Run(context.Background())
+	if !report.Up() || report.Checks["postgres"].Status != StatusUp {
+		t.Errorf("all checks up: %+v", report)
+	}
+
+	report = Checks{"postgres": up, "rabbitmq": down}.Run(context.Background())
+	if report.Up() {
+		t.Error("report is up with a failing check")
+	}
+	if got := report.Checks["rabbitmq"]; got.Status != StatusDown || got.Error != "connection refused" {
+		t.Errorf("rabbitmq = %+v", got)
+	}
+	if got := report.Checks["postgres"]; got.Status != StatusUp {
+		t.Errorf("postgres = %+v", got)
+	}
+}
+
+func TestRunBoundsEachCheck(t *testing.T) {
+	hung := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := Checks{"rabbitmq": hung}.Run(ctx)
+	if report.Up() || report.Checks["rabbitmq"].Error != context.Canceled.Error() {
+		t.Errorf("hung check = %+v", report.Checks["rabbitmq"])
+	}
+}
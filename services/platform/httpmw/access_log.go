@@ -0,0 +1,71 @@
+package httpmw
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// maxLoggedBody caps how much of an error response ends up in the access
+// log.
+const maxLoggedBody = 512
+
+// AccessLog writes one line per request with its route, status and
+// latency. Error responses also log the start of their body, which is
+// where handlers put the reason. It must run inside Trace so the line
+// carries the request id.
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("route", r.Pattern),
+			slog.Int("status", rec.status),
+			slog.Int("bytes", rec.bytes),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote_addr", r.RemoteAddr),
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case rec.status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case rec.status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		if level > slog.LevelInfo {
+			attrs = append(attrs, slog.String("error", string(bytes.TrimSpace(rec.body.Bytes()))))
+		}
+
+		slog.LogAttrs(r.Context(), level, "http request", attrs...)
+	})
+}
+
+// statusRecorder remembers the status code and size of the response and
+// keeps the start of error bodies for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+	body   bytes.Buffer
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status >= http.StatusBadRequest && rec.body.Len() < maxLoggedBody {
+		rec.body.Write(b[:min(len(b), maxLoggedBody-rec.body.Len())])
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
@@ -0,0 +1,66 @@
+// Package httpmw holds the HTTP middleware every service wraps its routes
+// in.
+package httpmw
+
+import (
+	"net/http"
+
+	"platform/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const maxRequestIDLength = 128
+
+// Trace assigns every request a correlation id, taken from X-Request-ID when
+// the caller sent a usable one, echoes it back in the response and stores it
+// in the request context, from where it follows the request into the events
+// it causes. It also continues the caller's W3C trace, if any, with a server
+// span named after the matched route.
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(tracing.RequestIDHeader)
+		if !validRequestID(id) {
+			id = tracing.NewCorrelationID()
+		}
+		w.Header().Set(tracing.RequestIDHeader, id)
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx = tracing.WithCorrelationID(ctx, id)
+		ctx, span := tracing.Start(ctx, r.Method,
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+			attribute.String("request.id", id),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rec, r)
+
+		// The mux fills in the pattern once it has matched the request.
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			span.SetAttributes(attribute.String("http.route", r.Pattern))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package httpmw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"platform/tracing"
+)
+
+func TestTraceRequestID(t *testing.T) {
+	tests := []struct {
+		name string
+		sent string
+		keep bool
+	}{
+		{"caller's id is kept", "req-42", true},
+		{"missing id is generated", "", false},
+		{"id with spaces is replaced", "a b", false},
+		{"overlong id is replaced", strings.Repeat("x", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			h := Trace(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = tracing.CorrelationID(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.sent != "" {
+				req.Header.Set(tracing.RequestIDHeader, tt.sent)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			echoed := rec.Header().Get(tracing.RequestIDHeader)
+			if echoed == "" || echoed != seen {
+				t.Fatalf("echoed %q, handler saw %q", echoed, seen)
+			}
+			if got := echoed == tt.sent; got != tt.keep {
+				t.Errorf("id %q for sent %q", echoed, tt.sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Package jwks verifies access tokens offline against the key set
+// user_service publishes.
+package jwks
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims matches the access token payload issued by user_service.
+type Claims struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Identity is the caller a valid token was issued to. Role is as the token
+// states it; the service checks it against the roles it knows.
+type Identity struct {
+	UserID int64
+	Role   string
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Verifier validates access tokens offline against the key set published by
+// user_service. Keys are cached and refetched when an unknown kid shows up,
+// at most once per minRefresh.
+type Verifier struct {
+	jwksURL    string
+	issuer     string
+	client     *http.Client
+	minRefresh time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func NewVerifier(jwksURL, issuer string) *Verifier {
+	return &Verifier{
+		jwksURL:    jwksURL,
+		issuer:     issuer,
+		client:     &http.Client{Timeout: 5 * time.Second},
+		minRefresh: 30 * time.Second,
+		keys:       map[string]*rsa.PublicKey{},
+	}
+}
+
+// Verify checks the signature, issuer and expiry of tokenString and returns
+// whom it was issued to.
+func (v *Verifier) Verify(tokenString string) (Identity, error) {
+	var claims Claims
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if v.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.issuer))
+	}
+
+	_, err := jwt.ParseWithClaims(tokenString, &claims, v.keyFunc, opts...)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil || userID <= 0 {
+		return Identity{}, fmt.Errorf("%w: bad subject", ErrInvalidToken)
+	}
+
+	return Identity{UserID: userID, Role: claims.Role}, nil
+}
+
+func (v *Verifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	if key := v.lookup(kid); key != nil {
+		return key, nil
+	}
+
+	if err := v.refresh(); err != nil {
+		return nil, err
+	}
+
+	if key := v.lookup(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (v *Verifier) lookup(kid string) *rsa.PublicKey {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.keys[kid]
+}
+
+func (v *Verifier) refresh() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if time.Since(v.fetchedAt) < v.minRefresh {
+		return nil
+	}
+	v.fetchedAt = time.Now()
+
+	resp, err := v.client.Get(v.jwksURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return err
+		}
+		keys[k.Kid] = key
+	}
+
+	v.keys = keys
+	return nil
+}
+
+func (k jwk) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWKS modulus for %q: %w", k.Kid, err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWKS exponent for %q: %w", k.Kid, err)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
@@ -0,0 +1,174 @@
+package jwks
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type signingKey struct {
+	kid string
+	key *rsa.PrivateKey
+}
+
+func newSigningKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return signingKey{kid: kid, key: key}
+}
+
+func (k signingKey) jwk() jwk {
+	return jwk{
+		Kty: "RSA",
+		Kid: k.kid,
+		N:   base64.RawURLEncoding.EncodeToString(k.key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.key.E)).Bytes()),
+	}
+}
+
+func (k signingKey) sign(t *testing.T, claims Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = k.kid
+	signed, err := token.SignedString(k.key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signed
+}
+
+func validClaims() Claims {
+	return Claims{
+		Role: "client",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "user_service",
+			Subject:   "42",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+}
+
+// jwksServer publishes the given keys and counts how often it is asked.
+type jwksServer struct {
+	*httptest.Server
+	keys    atomic.Value
+	fetches atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, keys ...signingKey) *jwksServer {
+	s := &jwksServer{}
+	s.publish(keys...)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys.Load()})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) publish(keys ...signingKey) {
+	set := make([]jwk, 0, len(keys))
+	for _, k := range keys {
+		set = append(set, k.jwk())
+	}
+	s.keys.Store(set)
+}
+
+func TestVerify(t *testing.T) {
+	key := newSigningKey(t, "k1")
+	server := newJWKSServer(t, key)
+	verifier := NewVerifier(server.URL, "user_service")
+
+	id, err := verifier.Verify(key.sign(t, validClaims()))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if id != (Identity{UserID: 42, Role: "client"}) {
+		t.Errorf("identity = %+v", id)
+	}
+
+	with := func(edit func(*Claims)) Claims {
+		c := validClaims()
+		edit(&c)
+		return c
+	}
+	hmac, err := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims()).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"garbage", "not.a.token"},
+		{"HMAC", hmac},
+		{"unknown key", newSigningKey(t, "k2").sign(t, validClaims())},
+		{"forged kid", signingKey{kid: "k1", key: newSigningKey(t, "x").key}.sign(t, validClaims())},
+		{"expired", key.sign(t, with(func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }))},
+		{"no expiry", key.sign(t, with(func(c *Claims) { c.ExpiresAt = nil }))},
+		{"other issuer", key.sign(t, with(func(c *Claims) { c.Issuer = "someone" }))},
+		{"bad subject", key.sign(t, with(func(c *Claims) { c.Subject = "0" }))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("got %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyRefetchesOnKeyRotation(t *testing.T) {
+	oldKey, newKey := newSigningKey(t, "old"), newSigningKey(t, "new")
+	server := newJWKSServer(t, oldKey)
+	verifier := NewVerifier(server.URL, "user_service")
+	verifier.minRefresh = 0
+
+	if _, err := verifier.Verify(oldKey.sign(t, validClaims())); err != nil {
+		t.Fatalf("Verify with the first key: %v", err)
+	}
+	if _, err := verifier.Verify(oldKey.sign(t, validClaims())); err != nil {
+		t.Fatalf("Verify again: %v", err)
+	}
+	if n := server.fetches.Load(); n != 1 {
+		t.Errorf("fetched the key set %d times, want 1 while the key is cached", n)
+	}
+
+	server.publish(oldKey, newKey)
+	if _, err := verifier.Verify(newKey.sign(t, validClaims())); err != nil {
+		t.Fatalf("Verify with the rotated key: %v", err)
+	}
+	if n := server.fetches.Load(); n != 2 {
+		t.Errorf("fetched the key set %d times, want 2 after an unknown kid", n)
+	}
+}
+
+func TestVerifyLimitsRefetches(t *testing.T) {
+	server := newJWKSServer(t, newSigningKey(t, "k1"))
+	verifier := NewVerifier(server.URL, "user_service")
+	stranger := newSigningKey(t, "k2")
+
+	for range 3 {
+		if _, err := verifier.Verify(stranger.sign(t, validClaims())); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("got %v, want ErrInvalidToken", err)
+		}
+	}
+	if n := server.fetches.Load(); n != 1 {
+		t.Errorf("fetched the key set %d times, want 1 within minRefresh", n)
+	}
+}
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"platform/tracing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Field names shared by every log line that mentions them, so logs of all
+// services can be filtered the same way.
+const (
+	KeyService   = "service"
+	KeyRequestID = "request_id"
+	KeyTraceID   = "trace_id"
+	KeyOrderID   = "order_id"
+	KeyUserID    = "user_id"
+	KeyProductID = "product_id"
+	KeyEventType = "event_type"
+	KeyMessageID = "message_id"
+	KeyError     = "error"
+)
+
+func OrderID(id int64) slog.Attr    { return slog.Int64(KeyOrderID, id) }
+func UserID(id int64) slog.Attr     { return slog.Int64(KeyUserID, id) }
+func ProductID(id int64) slog.Attr  { return slog.Int64(KeyProductID, id) }
+func EventType(t string) slog.Attr  { return slog.String(KeyEventType, t) }
+func MessageID(id string) slog.Attr { return slog.String(KeyMessageID, id) }
+func Err(err error) slog.Attr       { return slog.Any(KeyError, err) }
+
+// ParseLevel accepts debug, info, warn or error in any case; an empty
+// string means info.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if strings.TrimSpace(s) == "" {
+		return slog.LevelInfo, nil
+	}
+	err := level.UnmarshalText([]byte(strings.TrimSpace(s)))
+	return level, err
+}
+
+// New returns a JSON logger writing to w that stamps every record with
+// service and, when logged with a context, the request id, trace id and any
+// fields attached to that context with With.
+func New(w io.Writer, level slog.Leveler, service string) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+	return slog.New(&contextHandler{Handler: handler}).With(KeyService, service)
+}
+
+// Setup makes a JSON logger for service at level the default for slog and
+// for the standard log package.
+func Setup(level, service string) error {
+	l, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(New(os.Stdout, l, service))
+	return nil
+}
+
+// Fatal logs msg at error level and exits.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+type fieldsKey struct{}
+
+// With returns a context whose log records carry attrs in addition to the
+// ones already attached to ctx.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	current, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	fields := make([]slog.Attr, 0, len(current)+len(attrs))
+	fields = append(fields, current...)
+	fields = append(fields, attrs...)
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := tracing.CorrelationID(ctx); id != "" {
+		r.AddAttrs(slog.String(KeyRequestID, id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String(KeyTraceID, sc.TraceID().String()))
+	}
+	if fields, ok := ctx.Value(fieldsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(fields...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,276 @@
+// Package migrate applies a service's versioned SQL migrations to its
+// database and records them in schema_migrations.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one schema change with the SQL that applies and reverts it.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// State is a migration known to the binary or recorded in the database.
+// AppliedAt is nil while it is pending; Unknown marks a version the
+// database has but this binary does not, e.g. after a rollback of the
+// service.
+type State struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	Unknown   bool
+}
+
+// Migrator applies the migrations of one service.
+type Migrator struct {
+	files  fs.FS
+	lockID int64
+}
+
+// New returns a Migrator for the migrations at the root of files, named
+// <version>_<name>.up.sql and <version>_<name>.down.sql. Versions are
+// applied in ascending order and are never renumbered once released.
+//
+// lockID is the advisory lock held while migrating, so replicas starting
+// together apply each migration once. Services sharing a database need
+// different ones.
+func New(files fs.FS, lockID int64) *Migrator {
+	return &Migrator{files: files, lockID: lockID}
+}
+
+// All returns the migrations ordered by version.
+func (mr *Migrator) All() ([]Migration, error) {
+	entries, err := fs.ReadDir(mr.files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file %q", e.Name())
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+
+		body, err := fs.ReadFile(mr.files, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	all := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", mig.Version, mig.Name)
+		}
+		all = append(all, *mig)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	return all, nil
+}
+
+// Up applies every pending migration and returns how many it applied. Each
+// migration commits together with its schema_migrations row, so a failure
+// leaves the schema at the last migration that succeeded.
+func (mr *Migrator) Up(ctx context.Context, db *sql.DB) (int, error) {
+	all, err := mr.All()
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	err = mr.withLock(ctx, db, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range all {
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+			err := inTx(ctx, conn, m.Up,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			slog.InfoContext(ctx, "migration applied", "version", m.Version, "name", m.Name)
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the latest steps applied migrations, newest first, and
+// returns how many it reverted.
+func (mr *Migrator) Down(ctx context.Context, db *sql.DB, steps int) (int, error) {
+	all, err := mr.All()
+	if err != nil {
+		return 0, err
+	}
+	known := make(map[int64]Migration, len(all))
+	for _, m := range all {
+		known[m.Version] = m
+	}
+
+	reverted := 0
+	err = mr.withLock(ctx, db, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(done))
+		for v := range done {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, v := range versions[:min(steps, len(versions))] {
+			m, ok := known[v]
+			if !ok {
+				return fmt.Errorf("migration %d is applied but unknown to this binary", v)
+			}
+			err := inTx(ctx, conn, m.Down,
+				`DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+			if err != nil {
+				return fmt.Errorf("reverting migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			slog.InfoContext(ctx, "migration reverted", "version", m.Version, "name", m.Name)
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every migration, applied or not, ordered by version.
+func (mr *Migrator) Status(ctx context.Context, db *sql.DB) ([]State, error) {
+	all, err := mr.All()
+	if err != nil {
+		return nil, err
+	}
+
+	var states []State
+	err = mr.withLock(ctx, db, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range all {
+			s := State{Version: m.Version, Name: m.Name}
+			if r, ok := done[m.Version]; ok {
+				s.AppliedAt = &r.appliedAt
+				delete(done, m.Version)
+			}
+			states = append(states, s)
+		}
+		for v, r := range done {
+			states = append(states, State{Version: v, Name: r.name, AppliedAt: &r.appliedAt, Unknown: true})
+		}
+		return nil
+	})
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+	return states, err
+}
+
+// withLock runs fn on a single connection holding the migration lock, after
+// making sure schema_migrations exists.
+func (mr *Migrator) withLock(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, mr.lockID); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, mr.lockID)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+type appliedMigration struct {
+	name      string
+	appliedAt time.Time
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := map[int64]appliedMigration{}
+	for rows.Next() {
+		var v int64
+		var m appliedMigration
+		if err := rows.Scan(&v, &m.name, &m.appliedAt); err != nil {
+			return nil, err
+		}
+		done[v] = m
+	}
+	return done, rows.Err()
+}
+
+// inTx runs a migration script and the statement recording it in one
+// transaction.
+func inTx(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,55 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestAll(t *testing.T) {
+	files := fstest.MapFS{
+		"0002_add_index.up.sql":      {Data: []byte("CREATE INDEX")},
+		"0002_add_index.down.sql":    {Data: []byte("DROP INDEX")},
+		"0001_create_table.up.sql":   {Data: []byte("CREATE TABLE")},
+		"0001_create_table.down.sql": {Data: []byte("DROP TABLE")},
+	}
+
+	all, err := New(files, 1).All()
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "create_table", Up: "CREATE TABLE", Down: "DROP TABLE"},
+		{Version: 2, Name: "add_index", Up: "CREATE INDEX", Down: "DROP INDEX"},
+	}
+	if len(all) != len(want) {
+		t.Fatalf("got %d migrations, want %d", len(all), len(want))
+	}
+	for i := range want {
+		if all[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, all[i], want[i])
+		}
+	}
+}
+
+func TestAllRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"unexpected file", fstest.MapFS{"README.md": {}}},
+		{"missing down", fstest.MapFS{"0001_create_table.up.sql": {Data: []byte("CREATE TABLE")}}},
+		{"two names", fstest.MapFS{
+			"0001_create_table.up.sql": {Data: []byte("CREATE TABLE")},
+			"0001_drop_table.down.sql": {Data: []byte("DROP TABLE")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.files, 1).All(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Package postgres opens the PostgreSQL pool every service runs on.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"platform/logging"
+
+	_ "github.com/lib/pq"
+)
+
+const (
+	minConnectDelay = 500 * time.Millisecond
+	maxConnectDelay = 10 * time.Second
+)
+
+var sslModes = map[string]bool{
+	"disable":     true,
+	"allow":       true,
+	"prefer":      true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// Config is the db section of a service config.
+type Config struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+
+	// ConnectTimeout is how long Connect keeps retrying at startup.
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+
+	// MigrateOnStart applies pending migrations before the service starts
+	// serving. Without it they are applied with the migrate subcommand.
+	MigrateOnStart bool `yaml:"migrate_on_start"`
+}
+
+// Validate checks the connection and pool settings. DB_HOST, DB_USER and
+// DB_NAME are required; everything else has a default.
+func (c *Config) Validate() error {
+	var missing []string
+	for _, v := range []struct{ key, value string }{
+		{"DB_HOST", c.Host},
+		{"DB_USER", c.User},
+		{"DB_NAME", c.Name},
+	} {
+		if v.value == "" {
+			missing = append(missing, v.key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s not set", strings.Join(missing, ", "))
+	}
+	if !sslModes[c.SSLMode] {
+		return fmt.Errorf("invalid DB_SSLMODE %q", c.SSLMode)
+	}
+
+	if c.MaxOpenConns <= 0 {
+		return errors.New("DB_MAX_OPEN_CONNS must be positive")
+	}
+	if c.MaxIdleConns < 0 || c.MaxIdleConns > c.MaxOpenConns {
+		return errors.New("DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS")
+	}
+	if c.ConnMaxLifetime < 0 || c.ConnMaxIdleTime < 0 {
+		return errors.New("DB durations must not be negative")
+	}
+	if c.ConnectTimeout <= 0 {
+		return errors.New("DB_CONNECT_TIMEOUT must be positive")
+	}
+
+	return nil
+}
+
+// DSN is the lib/pq connection string for cfg.
+func (c *Config) DSN() string {
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		quoteDSN(c.Host),
+		quoteDSN(c.Port),
+		quoteDSN(c.User),
+		quoteDSN(c.Password),
+		quoteDSN(c.Name),
+		quoteDSN(c.SSLMode),
+	)
+}
+
+// quoteDSN quotes a value for a key=value connection string, so passwords
+// with spaces or quotes survive.
+func quoteDSN(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+// Connect opens a pool configured by cfg and pings the database until it
+// answers, backing off between attempts, so a service started alongside
+// its database waits for it instead of failing later. It gives up after
+// cfg.ConnectTimeout or when ctx ends.
+func Connect(ctx context.Context, cfg *Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+
+	delay := minConnectDelay
+	for {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return db, nil
+		}
+
+		slog.Warn("database not ready", "retry_in", delay.String(), logging.Err(err))
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, fmt.Errorf("database not reachable: %w", err)
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxConnectDelay)
+	}
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDBConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+	}{
+		{"missing host", func(c *Config) { c.Host = "" }},
+		{"bad sslmode", func(c *Config) { c.SSLMode = "sometimes" }},
+		{"no open conns", func(c *Config) { c.MaxOpenConns = 0 }},
+		{"more idle than open", func(c *Config) { c.MaxIdleConns = c.MaxOpenConns + 1 }},
+		{"negative duration", func(c *Config) { c.ConnMaxLifetime = -1 }},
+		{"no connect timeout", func(c *Config) { c.ConnectTimeout = 0 }},
+	}
+
+	valid := Config{
+		Host:            "db",
+		Port:            "5432",
+		User:            "orders",
+		Name:            "orders",
+		SSLMode:         "require",
+		MaxOpenConns:    25,
+		MaxIdleConns:    10,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+		ConnectTimeout:  time.Minute,
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Error("got no error")
+			}
+		})
+	}
+}
+
+func TestDSNQuotesValues(t *testing.T) {
+	cfg := &Config{Host: "db", Port: "5432", User: "orders", Password: `it's a \secret`, Name: "orders", SSLMode: "disable"}
+
+	want := `host='db' port='5432' user='orders' password='it\'s a \\secret' dbname='orders' sslmode='disable'`
+	if got := cfg.DSN(); got != want {
+		t.Errorf("DSN() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+// Package problem writes error responses as RFC 7807 problem details.
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"platform/domainerr"
+	"platform/logging"
+)
+
+const ContentType = "application/problem+json"
+
+// Details is the body of an error response. Type is always about:blank, so
+// Title is the text of Status; Detail says what went wrong in this case.
+type Details struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// Write sends a problem with status and detail in reply to r.
+func Write(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Details{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
+
+// Error sends err with the status of its domain error kind. Errors of no
+// kind are answered with a bare 500 and logged, so internals never reach
+// the caller.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	status := Status(err)
+	switch status {
+	case http.StatusInternalServerError:
+		slog.ErrorContext(r.Context(), "request failed", logging.Err(err))
+		Write(w, r, status, "")
+	case http.StatusServiceUnavailable:
+		Write(w, r, status, "request timed out")
+	default:
+		Write(w, r, status, err.Error())
+	}
+}
+
+// Status maps err to an HTTP status code.
+func Status(err error) int {
+	switch {
+	case errors.Is(err, domainerr.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, domainerr.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domainerr.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domainerr.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domainerr.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,70 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"platform/domainerr"
+)
+
+var (
+	errOrderNotFound  = &domainerr.Error{Kind: domainerr.ErrNotFound, Msg: "order not found"}
+	errStatusConflict = &domainerr.Error{Kind: domainerr.ErrConflict, Msg: "order status changed concurrently"}
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{domainerr.Invalid("quantity must be positive"), http.StatusBadRequest},
+		{domainerr.ErrUnauthorized, http.StatusUnauthorized},
+		{domainerr.ErrForbidden, http.StatusForbidden},
+		{errOrderNotFound, http.StatusNotFound},
+		{fmt.Errorf("confirm order 1: %w", errStatusConflict), http.StatusConflict},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusServiceUnavailable},
+		{errors.New("pq: connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if got := Status(tt.err); got != tt.want {
+			t.Errorf("Status(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestErrorHidesInternals(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+		wantDetail string
+	}{
+		{errOrderNotFound, http.StatusNotFound, "order not found"},
+		{errors.New("pq: password authentication failed"), http.StatusInternalServerError, ""},
+		{context.DeadlineExceeded, http.StatusServiceUnavailable, "request timed out"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		Error(rec, httptest.NewRequest(http.MethodGet, "/orders/1", nil), tt.err)
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%v: status %d, want %d", tt.err, rec.Code, tt.wantStatus)
+		}
+		if got := rec.Header().Get("Content-Type"); got != ContentType {
+			t.Errorf("%v: content type %q", tt.err, got)
+		}
+		var body Details
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("%v: decode: %v", tt.err, err)
+		}
+		if body.Status != tt.wantStatus || body.Detail != tt.wantDetail || body.Instance != "/orders/1" {
+			t.Errorf("%v: body %+v", tt.err, body)
+		}
+	}
+}
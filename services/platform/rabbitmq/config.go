@@ -0,0 +1,30 @@
+package rabbitmq
+
+import (
+	"errors"
+	"net/url"
+)
+
+// Config is the part of a service's rabbitmq config section every service
+// has; services with consumers add their queue names next to it.
+type Config struct {
+	URL string `yaml:"url"`
+
+	// Exchange is the topic exchange every service publishes events to.
+	Exchange string `yaml:"exchange"`
+}
+
+// Validate checks that RABBITMQ_URL is an AMQP URL and an exchange is set.
+func (c *Config) Validate() error {
+	if c.URL == "" {
+		return errors.New("RABBITMQ_URL is not set")
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "amqp" && u.Scheme != "amqps") {
+		return errors.New("RABBITMQ_URL must start with amqp:// or amqps://")
+	}
+	if c.Exchange == "" {
+		return errors.New("RABBITMQ_EXCHANGE must not be empty")
+	}
+	return nil
+}
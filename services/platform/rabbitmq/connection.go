@@ -0,0 +1,217 @@
+// Package rabbitmq holds what every service needs to talk to RabbitMQ: a
+// self-healing connection and the consumer runner with its retry and
+// dead-letter topology.
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"platform/logging"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+var (
+	ErrConnectionClosed = errors.New("rabbitmq connection closed")
+	ErrConnectionDown   = errors.New("rabbitmq connection down, reconnecting")
+)
+
+// Connection keeps a single AMQP connection alive for the whole service.
+// When the broker goes away it redials with exponential backoff, declares
+// the exchange again and runs every OnReconnect hook so consumers
+// and their topology come back without a restart.
+type Connection struct {
+	url      string
+	exchange string
+
+	mu     sync.RWMutex
+	conn   *amqp.Connection
+	hooks  []func() error
+	closed bool
+}
+
+// Dial connects to url and declares exchange, the topic exchange events are
+// published to. The initial
+// connection is not retried; callers decide whether failing to reach the
+// broker at startup is fatal.
+func Dial(url, exchange string) (*Connection, error) {
+	c := &Connection{url: url, exchange: exchange}
+
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+
+	go c.watch(conn)
+	return c, nil
+}
+
+// Exchange is the name of the topic exchange declared on every connect.
+func (c *Connection) Exchange() string {
+	return c.exchange
+}
+
+// Channel opens a new channel on the current connection.
+func (c *Connection) Channel() (*amqp.Channel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return nil, ErrConnectionClosed
+	}
+	return c.conn.Channel()
+}
+
+// Check reports whether the connection is currently open. It does not talk
+// to the broker; a dropped connection is noticed by the client library and
+// shows up here until it has been re-established.
+func (c *Connection) Check(ctx context.Context) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return ErrConnectionClosed
+	}
+	if c.conn.IsClosed() {
+		return ErrConnectionDown
+	}
+	return nil
+}
+
+// OnReconnect registers fn to run every time the connection has been
+// re-established. Hooks run in registration order.
+func (c *Connection) OnReconnect(fn func() error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks, fn)
+}
+
+// Close shuts the connection down for good; it will not be re-established.
+func (c *Connection) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.conn.Close()
+}
+
+func (c *Connection) isClosed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.closed
+}
+
+func (c *Connection) connect() (*amqp.Connection, error) {
+	conn, err := amqp.Dial(c.url)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	defer ch.Close()
+
+	err = ch.ExchangeDeclare(
+		c.exchange,
+		"topic",
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// watch waits for conn to drop and replaces it, for as long as the
+// Connection has not been closed by the service itself.
+func (c *Connection) watch(conn *amqp.Connection) {
+	for {
+		reason, ok := <-conn.NotifyClose(make(chan *amqp.Error, 1))
+		if c.isClosed() {
+			return
+		}
+		if ok {
+			slog.Warn("rabbitmq connection lost", logging.Err(reason))
+		} else {
+			slog.Warn("rabbitmq connection lost")
+		}
+
+		conn = c.reconnect()
+		if conn == nil {
+			return
+		}
+	}
+}
+
+// reconnect dials until it succeeds and every hook has run on the new
+// connection. It returns nil if the Connection is closed meanwhile.
+func (c *Connection) reconnect() *amqp.Connection {
+	delay := minReconnectDelay
+
+	for {
+		time.Sleep(delay)
+		if delay < maxReconnectDelay {
+			delay = min(delay*2, maxReconnectDelay)
+		}
+
+		if c.isClosed() {
+			return nil
+		}
+
+		conn, err := c.connect()
+		if err != nil {
+			slog.Warn("rabbitmq reconnect failed", "retry_in", delay.String(), logging.Err(err))
+			continue
+		}
+
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			conn.Close()
+			return nil
+		}
+		c.conn = conn
+		hooks := append([]func() error(nil), c.hooks...)
+		c.mu.Unlock()
+
+		if err := runHooks(hooks); err != nil {
+			slog.Error("rabbitmq recovery failed", logging.Err(err))
+			conn.Close()
+			continue
+		}
+
+		slog.Info("rabbitmq connection re-established")
+		return conn
+	}
+}
+
+func runHooks(hooks []func() error) error {
+	for _, hook := range hooks {
+		if err := hook(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,463 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"platform/logging"
+	"platform/tracing"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// maxRetries is how many times a failing message is redelivered through
+	// the delay queues before it is dead-lettered.
+	maxRetries     = 5
+	baseRetryDelay = time.Second
+
+	prefetchCount = 10
+
+	// handlerTimeout is the deadline of a single delivery. A handler that
+	// runs out of time fails like any other and the message is retried.
+	handlerTimeout = 30 * time.Second
+
+	headerRetryCount     = "x-retry-count"
+	headerRoutingKey     = "x-original-routing-key"
+	headerError          = "x-last-error"
+	headerDeadLetteredAt = "x-dead-lettered-at"
+)
+
+// Handler processes a single delivery. ctx carries the correlation id and
+// trace of the request that caused the event. Returning nil acknowledges it;
+// any other error schedules a retry, unless it was wrapped with Permanent.
+type Handler func(ctx context.Context, msg amqp.Delivery) error
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, e.g. a payload that cannot be
+// decoded. Such messages go straight to the dead-letter queue.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// ConsumerRunner owns the retry and dead-letter topology of every queue it
+// consumes and acknowledges a message only once its handler has succeeded,
+// or once the broker has confirmed the copy sent on to a retry or dead-letter
+// queue.
+//
+// For a queue Q bound to the events exchange it declares:
+//   - Q.retry.1 … Q.retry.N, delay queues whose TTL doubles from
+//     baseRetryDelay and which dead-letter back into Q when it expires;
+//   - Q.dlq, where messages land after N failed retries or a Permanent
+//     error, until they are replayed from the admin API.
+//
+// Consumers are registered up front and started together; after the
+// connection is re-established the runner declares everything again and
+// resumes consuming on a fresh channel, until Stop is called.
+type ConsumerRunner struct {
+	conn      *Connection
+	fwd       *forwarder
+	consumers []consumer
+	queues    map[string]bool
+
+	// ctx is the parent of every handler's context; cancel aborts the
+	// handlers still running when Stop gives up waiting for them.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+
+	mu sync.Mutex
+	// active maps a queue to the channel its consumer goroutine runs on.
+	// A goroutine left over from before a reconnect may exit after its
+	// successor started, so it only clears the entry if it is still its own.
+	active  map[string]*amqp.Channel
+	ch      *amqp.Channel
+	stopped bool
+}
+
+type consumer struct {
+	queue      string
+	routingKey string
+	handle     Handler
+}
+
+func NewConsumerRunner(conn *Connection) *ConsumerRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ConsumerRunner{
+		conn:   conn,
+		fwd:    &forwarder{conn: conn},
+		queues: map[string]bool{},
+		ctx:    ctx,
+		cancel: cancel,
+		active: map[string]*amqp.Channel{},
+	}
+}
+
+// Register adds a consumer for queue, bound to routingKey on the events
+// exchange. It takes effect on Start.
+func (r *ConsumerRunner) Register(queue, routingKey string, handle Handler) {
+	r.consumers = append(r.consumers, consumer{
+		queue:      queue,
+		routingKey: routingKey,
+		handle:     handle,
+	})
+	r.queues[queue] = true
+}
+
+// Start declares the topology of every registered consumer and starts
+// handling deliveries, now and after every reconnect.
+func (r *ConsumerRunner) Start() error {
+	if err := r.start(); err != nil {
+		return err
+	}
+	r.conn.OnReconnect(r.start)
+	return nil
+}
+
+func (r *ConsumerRunner) start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return nil
+	}
+
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return err
+	}
+
+	if err := ch.Qos(prefetchCount, 0, false); err != nil {
+		ch.Close()
+		return err
+	}
+
+	for _, c := range r.consumers {
+		if err := r.consume(ch, c); err != nil {
+			ch.Close()
+			return err
+		}
+	}
+
+	r.ch = ch
+	return nil
+}
+
+// Stop cancels every consumer so the broker sends no more deliveries and
+// waits for the handlers already running. If ctx expires first, their
+// contexts are cancelled and ctx's error is returned. The channel is closed
+// either way, and the broker requeues whatever was not acknowledged. Stopped
+// consumers are not restarted by a reconnect.
+func (r *ConsumerRunner) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	r.stopped = true
+	ch := r.ch
+	r.mu.Unlock()
+
+	if ch == nil {
+		return nil
+	}
+	defer ch.Close()
+
+	for _, c := range r.consumers {
+		if err := ch.Cancel(c.queue, false); err != nil {
+			// The channel is gone, and its consumers with it.
+			break
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		return ctx.Err()
+	}
+}
+
+func (r *ConsumerRunner) consume(ch *amqp.Channel, c consumer) error {
+	if err := declare(ch, r.conn.Exchange(), c.queue, c.routingKey); err != nil {
+		return err
+	}
+
+	// The queue name doubles as the consumer tag, which Stop cancels.
+	msgs, err := ch.Consume(
+		c.queue,
+		c.queue,
+		false,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	// msgs is closed when the consumer is cancelled or the channel or
+	// connection goes away; start runs again once the connection is back.
+	r.active[c.queue] = ch
+	r.workers.Add(1)
+	go func() {
+		defer r.workers.Done()
+		defer r.clearActive(c.queue, ch)
+		for msg := range msgs {
+			// Deliveries prefetched before Stop but not started yet
+			// go straight back to the queue.
+			if r.isStopped() {
+				msg.Nack(false, true)
+				continue
+			}
+			dispatch(r.ctx, r.fwd, c.queue, msg, c.handle)
+		}
+	}()
+
+	return nil
+}
+
+func (r *ConsumerRunner) clearActive(queue string, ch *amqp.Channel) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.active[queue] == ch {
+		delete(r.active, queue)
+	}
+}
+
+func (r *ConsumerRunner) isStopped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stopped
+}
+
+// Alive reports an error naming every registered queue that currently has
+// no consumer goroutine, e.g. because its channel was closed by the broker
+// or the connection is still being re-established.
+func (r *ConsumerRunner) Alive(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var stopped []string
+	for queue := range r.queues {
+		if r.active[queue] == nil {
+			stopped = append(stopped, queue)
+		}
+	}
+	if len(stopped) == 0 {
+		return nil
+	}
+
+	sort.Strings(stopped)
+	return fmt.Errorf("no consumer for %s", strings.Join(stopped, ", "))
+}
+
+// Live is Alive for liveness probes: consumers missing while the connection
+// is down are expected and come back with it, so they do not count. Missing
+// on an open connection, they would never come back without a restart.
+func (r *ConsumerRunner) Live(ctx context.Context) error {
+	if r.conn.Check(ctx) != nil {
+		return nil
+	}
+	return r.Alive(ctx)
+}
+
+// QueueDepths reports how many messages are ready in every registered queue
+// and its dead-letter queue. The queues are inspected on a throwaway channel.
+func (r *ConsumerRunner) QueueDepths() (map[string]int, error) {
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	depths := make(map[string]int, 2*len(r.queues))
+	for queue := range r.queues {
+		for _, name := range []string{queue, deadLetterQueue(queue)} {
+			q, err := ch.QueueInspect(name)
+			if err != nil {
+				return nil, err
+			}
+			depths[name] = q.Messages
+		}
+	}
+
+	return depths, nil
+}
+
+func declare(ch *amqp.Channel, exchange, queue, routingKey string) error {
+	if _, err := ch.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	if err := ch.QueueBind(queue, routingKey, exchange, false, nil); err != nil {
+		return err
+	}
+
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		_, err := ch.QueueDeclare(
+			retryQueue(queue, attempt),
+			true,
+			false,
+			false,
+			false,
+			amqp.Table{
+				"x-message-ttl":             int64(retryDelay(attempt) / time.Millisecond),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queue,
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := ch.QueueDeclare(deadLetterQueue(queue), true, false, false, false, nil)
+	return err
+}
+
+// dispatch handles msg with a context derived from parent, the runner's
+// context.
+func dispatch(parent context.Context, fwd *forwarder, queue string, msg amqp.Delivery, handle Handler) {
+	eventType := OriginalRoutingKey(msg)
+	ctx, span := tracing.Start(
+		tracing.Extract(parent, tracing.FromTable(msg.Headers)),
+		"consume "+queue,
+		attribute.String("messaging.system", "rabbitmq"),
+		attribute.String("messaging.source.name", queue),
+		attribute.String("messaging.rabbitmq.destination.routing_key", eventType),
+		attribute.String("messaging.message.id", msg.MessageId),
+		attribute.Int("messaging.rabbitmq.retry_count", retryCount(msg)),
+	)
+	ctx = logging.With(ctx,
+		logging.EventType(eventType),
+		logging.MessageID(msg.MessageId),
+		slog.String("queue", queue),
+	)
+	ctx, cancel := context.WithTimeout(ctx, handlerTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := handle(ctx, msg)
+	tracing.End(span, err)
+	EventDuration.WithLabelValues(queue).Observe(time.Since(start).Seconds())
+	if err == nil {
+		EventsConsumed.WithLabelValues(queue, eventType, OutcomeHandled).Inc()
+		slog.DebugContext(ctx, "message handled", "duration_ms", time.Since(start).Milliseconds())
+		if ackErr := msg.Ack(false); ackErr != nil {
+			slog.ErrorContext(ctx, "failed to ack message", logging.Err(ackErr))
+		}
+		return
+	}
+
+	if parent.Err() != nil {
+		// Cut off by shutdown: not the message's fault, so it goes back
+		// to the queue without using up a retry.
+		slog.WarnContext(ctx, "message interrupted by shutdown, requeueing", logging.Err(err))
+		msg.Nack(false, true)
+		return
+	}
+
+	attempt := retryCount(msg) + 1
+
+	var permanent *permanentError
+	deadLetter := errors.As(err, &permanent) || attempt > maxRetries
+
+	var target, outcome string
+	if deadLetter {
+		slog.ErrorContext(ctx, "dead-lettering message", "attempt", attempt, logging.Err(err))
+		target, outcome = deadLetterQueue(queue), OutcomeDeadLettered
+	} else {
+		slog.WarnContext(ctx, "retrying message", "attempt", attempt, "retry_in", retryDelay(attempt).String(), logging.Err(err))
+		target, outcome = retryQueue(queue, attempt), OutcomeRetried
+	}
+	EventsConsumed.WithLabelValues(queue, eventType, outcome).Inc()
+
+	if pubErr := forward(fwd, msg, target, attempt, err, deadLetter); pubErr != nil {
+		// Leave the message with the broker rather than lose it.
+		slog.ErrorContext(ctx, "failed to forward message", "target", target, logging.Err(pubErr))
+		msg.Nack(false, true)
+		return
+	}
+
+	if ackErr := msg.Ack(false); ackErr != nil {
+		slog.ErrorContext(ctx, "failed to ack message", logging.Err(ackErr))
+	}
+}
+
+// forward republishes msg to target through the default exchange, keeping
+// its id and body and recording why it left the main queue. It returns once
+// the broker has confirmed the copy.
+func forward(fwd *forwarder, msg amqp.Delivery, target string, attempt int, cause error, deadLetter bool) error {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	if _, ok := headers[headerRoutingKey]; !ok {
+		headers[headerRoutingKey] = msg.RoutingKey
+	}
+	headers[headerRetryCount] = int32(attempt)
+	headers[headerError] = cause.Error()
+	if deadLetter {
+		headers[headerDeadLetteredAt] = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	return fwd.publish(target, amqp.Publishing{
+		ContentType:  msg.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    msg.MessageId,
+		Headers:      headers,
+		Body:         msg.Body,
+	})
+}
+
+// OriginalRoutingKey is the key msg was first published with; deliveries
+// coming back from a delay queue carry the queue name instead.
+func OriginalRoutingKey(msg amqp.Delivery) string {
+	if key, ok := msg.Headers[headerRoutingKey].(string); ok {
+		return key
+	}
+	return msg.RoutingKey
+}
+
+func retryCount(msg amqp.Delivery) int {
+	switch v := msg.Headers[headerRetryCount].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
+func retryDelay(attempt int) time.Duration {
+	return baseRetryDelay << (attempt - 1)
+}
+
+func retryQueue(queue string, attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", queue, attempt)
+}
+
+func deadLetterQueue(queue string) string {
+	return queue + ".dlq"
+}
@@ -0,0 +1,93 @@
+package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+func TestRetrySchedule(t *testing.T) {
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second}
+	if len(want) != maxRetries {
+		t.Fatalf("schedule covers %d retries, maxRetries is %d", len(want), maxRetries)
+	}
+	for i, d := range want {
+		attempt := i + 1
+		if got := retryDelay(attempt); got != d {
+			t.Errorf("retryDelay(%d) = %s, want %s", attempt, got, d)
+		}
+		if got, want := retryQueue("orders", attempt), fmt.Sprintf("orders.retry.%d", attempt); got != want {
+			t.Errorf("retryQueue = %s, want %s", got, want)
+		}
+	}
+	if got := deadLetterQueue("orders"); got != "orders.dlq" {
+		t.Errorf("deadLetterQueue = %s", got)
+	}
+}
+
+func TestRetryCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers amqp.Table
+		want    int
+	}{
+		{"first delivery", nil, 0},
+		{"int32", amqp.Table{headerRetryCount: int32(2)}, 2},
+		{"int64", amqp.Table{headerRetryCount: int64(3)}, 3},
+		{"not a number", amqp.Table{headerRetryCount: "4"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryCount(amqp.Delivery{Headers: tt.headers}); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	cause := errors.New("bad payload")
+	err := fmt.Errorf("handle: %w", Permanent(cause))
+
+	var permanent *permanentError
+	if !errors.As(err, &permanent) {
+		t.Error("wrapped Permanent error is not recognised")
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Permanent hides its cause")
+	}
+}
+
+func TestAwaitConfirm(t *testing.T) {
+	closed := make(chan amqp.Confirmation)
+	close(closed)
+
+	tests := []struct {
+		name     string
+		confirms chan amqp.Confirmation
+		want     error
+	}{
+		{"ack", confirmed(true), nil},
+		{"nack", confirmed(false), errForwardNacked},
+		{"channel closed", closed, ErrConnectionClosed},
+		{"no confirm", make(chan amqp.Confirmation), errForwardTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := awaitConfirm(tt.confirms, 10*time.Millisecond); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func confirmed(ack bool) chan amqp.Confirmation {
+	confirms := make(chan amqp.Confirmation, 1)
+	confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: ack}
+	return confirms
+}
@@ -0,0 +1,116 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"time"
+
+	"platform/domainerr"
+
+	"github.com/streadway/amqp"
+)
+
+var ErrUnknownQueue = &domainerr.Error{Kind: domainerr.ErrNotFound, Msg: "unknown queue"}
+
+// DeadLetter is a message parked in a consumer's dead-letter queue.
+type DeadLetter struct {
+	MessageID      string    `json:"message_id"`
+	RoutingKey     string    `json:"routing_key"`
+	Attempts       int       `json:"attempts"`
+	LastError      string    `json:"last_error"`
+	DeadLetteredAt time.Time `json:"dead_lettered_at"`
+	Payload        string    `json:"payload"`
+}
+
+// DeadLetters returns up to limit messages from queue's dead-letter queue
+// without removing them. The messages are fetched on a throwaway channel and
+// go back to the queue when it closes.
+func (r *ConsumerRunner) DeadLetters(queue string, limit int) ([]*DeadLetter, error) {
+	if !r.queues[queue] {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownQueue, queue)
+	}
+
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	letters := []*DeadLetter{}
+	for len(letters) < limit {
+		msg, ok, err := ch.Get(deadLetterQueue(queue), false)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		letters = append(letters, toDeadLetter(msg))
+	}
+
+	return letters, nil
+}
+
+// ReplayDeadLetters moves up to limit messages from queue's dead-letter queue
+// back onto queue with a fresh retry budget and reports how many were moved.
+// A message leaves the dead-letter queue only once its copy is confirmed.
+func (r *ConsumerRunner) ReplayDeadLetters(queue string, limit int) (int, error) {
+	if !r.queues[queue] {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownQueue, queue)
+	}
+
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	replayed := 0
+	for replayed < limit {
+		msg, ok, err := ch.Get(deadLetterQueue(queue), false)
+		if err != nil {
+			return replayed, err
+		}
+		if !ok {
+			break
+		}
+
+		headers := amqp.Table{}
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		delete(headers, headerRetryCount)
+		delete(headers, headerDeadLetteredAt)
+
+		err = r.fwd.publish(queue, amqp.Publishing{
+			ContentType:  msg.ContentType,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    msg.MessageId,
+			Headers:      headers,
+			Body:         msg.Body,
+		})
+		if err != nil {
+			return replayed, err
+		}
+
+		if err := msg.Ack(false); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+func toDeadLetter(msg amqp.Delivery) *DeadLetter {
+	letter := &DeadLetter{
+		MessageID: msg.MessageId,
+		Attempts:  retryCount(msg),
+		Payload:   string(msg.Body),
+	}
+	letter.RoutingKey, _ = msg.Headers[headerRoutingKey].(string)
+	letter.LastError, _ = msg.Headers[headerError].(string)
+	if at, ok := msg.Headers[headerDeadLetteredAt].(string); ok {
+		letter.DeadLetteredAt, _ = time.Parse(time.RFC3339, at)
+	}
+	return letter
+}
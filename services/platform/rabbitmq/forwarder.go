@@ -0,0 +1,107 @@
+package rabbitmq
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const confirmTimeout = 5 * time.Second
+
+var (
+	errForwardNacked  = errors.New("forwarded message was nacked by the broker")
+	errForwardTimeout = errors.New("timed out waiting for the forwarded message's confirm")
+)
+
+// forwarder publishes the messages a ConsumerRunner moves between queues on
+// a channel of its own in confirm mode, one at a time, so a delivery can be
+// acknowledged knowing its copy is stored. The channel is reopened on the
+// next publish after it has been closed, e.g. by a reconnect.
+type forwarder struct {
+	conn *Connection
+
+	mu       sync.Mutex
+	ch       *amqp.Channel
+	closed   chan *amqp.Error
+	confirms chan amqp.Confirmation
+}
+
+// publish sends msg to queue through the default exchange and waits for the
+// broker to confirm it.
+func (f *forwarder) publish(queue string, msg amqp.Publishing) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch, err := f.channel()
+	if err != nil {
+		return err
+	}
+
+	if err := ch.Publish("", queue, false, false, msg); err != nil {
+		f.reset()
+		return err
+	}
+
+	err = awaitConfirm(f.confirms, confirmTimeout)
+	if errors.Is(err, ErrConnectionClosed) || errors.Is(err, errForwardTimeout) {
+		// A late confirm would be mistaken for the next message's, so
+		// start over on a new channel.
+		f.reset()
+	}
+	return err
+}
+
+// awaitConfirm waits up to timeout for the confirm of the single message in
+// flight.
+func awaitConfirm(confirms <-chan amqp.Confirmation, timeout time.Duration) error {
+	select {
+	case confirm, ok := <-confirms:
+		if !ok {
+			return ErrConnectionClosed
+		}
+		if !confirm.Ack {
+			return errForwardNacked
+		}
+		return nil
+
+	case <-time.After(timeout):
+		return errForwardTimeout
+	}
+}
+
+// channel must be called with f.mu held.
+func (f *forwarder) channel() (*amqp.Channel, error) {
+	if f.ch != nil {
+		select {
+		case <-f.closed:
+			f.ch = nil
+		default:
+			return f.ch, nil
+		}
+	}
+
+	ch, err := f.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	f.ch = ch
+	f.closed = ch.NotifyClose(make(chan *amqp.Error, 1))
+	f.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+	return ch, nil
+}
+
+// reset must be called with f.mu held.
+func (f *forwarder) reset() {
+	if f.ch != nil {
+		f.ch.Close()
+		f.ch = nil
+	}
+}
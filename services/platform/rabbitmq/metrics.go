@@ -0,0 +1,25 @@
+package rabbitmq
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Outcomes of consuming an event.
+const (
+	OutcomeHandled      = "handled"
+	OutcomeRetried      = "retried"
+	OutcomeDeadLettered = "dead_lettered"
+)
+
+// Consumer metrics. They are not registered anywhere; a service running a
+// ConsumerRunner adds them to its own registry.
+var (
+	EventsConsumed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_consumed_total",
+		Help: "Deliveries handled by consumers by queue, routing key and outcome.",
+	}, []string{"queue", "routing_key", "outcome"})
+
+	EventDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "event_handle_duration_seconds",
+		Help:    "Time spent in a consumer's handler by queue.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"queue"})
+)
@@ -0,0 +1,169 @@
+// Package settings loads a service's config struct from defaults, a YAML
+// file, the environment and flags, in that order of precedence.
+package settings
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secrets when a config is printed.
+const redacted = "REDACTED"
+
+// Setting binds a field of the config struct C to its environment variable
+// and flag. The field's YAML key comes from its yaml tag.
+type Setting[C any] struct {
+	env   string
+	flag  string
+	usage string
+	field func(c *C) any
+}
+
+// Bind returns the Setting for the field that field points into, which must
+// be a string, int, bool or time.Duration.
+func Bind[C any](env, flag, usage string, field func(c *C) any) Setting[C] {
+	return Setting[C]{env: env, flag: flag, usage: usage, field: field}
+}
+
+// Options are the command-line options Load handles itself rather than
+// storing in the config.
+type Options struct {
+	// PrintConfig is set by --print-config: print the config and exit.
+	PrintConfig bool
+
+	// Args are the arguments left after the flags, e.g. a subcommand.
+	Args []string
+}
+
+// Load overlays cfg, which holds the defaults, with the YAML file named by
+// --config or CONFIG_FILE, then the environment, then the flags in args.
+// args excludes the program name. Validating the result is up to the
+// caller.
+func Load[C any](args []string, cfg *C, settings []Setting[C]) (Options, error) {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	file := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML config file ($CONFIG_FILE)")
+	printConfig := fs.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+
+	// Flags are applied after the file and the environment, so for now
+	// only remember which ones were given.
+	flags := map[string]string{}
+	for _, s := range settings {
+		fs.Func(s.flag, fmt.Sprintf("%s ($%s)", s.usage, s.env), func(v string) error {
+			flags[s.flag] = v
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return Options{}, err
+	}
+
+	if *file != "" {
+		if err := loadFile(cfg, *file); err != nil {
+			return Options{}, err
+		}
+	}
+
+	for _, s := range settings {
+		if v := os.Getenv(s.env); v != "" {
+			if err := set(s.field(cfg), v); err != nil {
+				return Options{}, fmt.Errorf("invalid %s: %w", s.env, err)
+			}
+		}
+	}
+	for _, s := range settings {
+		if v, ok := flags[s.flag]; ok {
+			if err := set(s.field(cfg), v); err != nil {
+				return Options{}, fmt.Errorf("invalid -%s: %w", s.flag, err)
+			}
+		}
+	}
+
+	return Options{PrintConfig: *printConfig, Args: fs.Args()}, nil
+}
+
+// loadFile overlays the YAML file at path on cfg. Keys it does not know are
+// an error, so a typo does not silently leave the default in place.
+func loadFile(cfg any, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// set parses v into the field p points to.
+func set(p any, v string) error {
+	switch p := p.(type) {
+	case *string:
+		*p = v
+	case *int:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*p = n
+	case *bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*p = b
+	case *time.Duration:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*p = d
+	default:
+		panic(fmt.Sprintf("settings: unsupported field type %T", p))
+	}
+	return nil
+}
+
+// WriteYAML writes v in the shape of the config file.
+func WriteYAML(w io.Writer, v any) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Redact hides a secret, leaving an empty one empty so it still shows as
+// unset.
+func Redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+// RedactURL hides the user info of rawURL, keeping the rest readable.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redacted
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(redacted, redacted)
+	} else if u.User != nil {
+		u.User = url.User(redacted)
+	}
+	return u.String()
+}
@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+)
+
+// Headers is a string map used to persist trace context and the correlation
+// id next to an outbox message, and to copy them into and out of AMQP
+// headers.
+type Headers map[string]string
+
+func (h Headers) Get(key string) string { return h[key] }
+func (h Headers) Set(key, value string) { h[key] = value }
+func (h Headers) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Inject captures the span context and correlation id of ctx.
+func Inject(ctx context.Context) Headers {
+	h := Headers{}
+	otel.GetTextMapPropagator().Inject(ctx, h)
+	if id := CorrelationID(ctx); id != "" {
+		h[CorrelationIDHeader] = id
+	}
+	return h
+}
+
+// Extract restores what Inject captured onto ctx.
+func Extract(ctx context.Context, h Headers) context.Context {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, h)
+	if id := h[CorrelationIDHeader]; id != "" {
+		ctx = WithCorrelationID(ctx, id)
+	}
+	return ctx
+}
+
+// FromTable picks the string headers of an AMQP message, which is where
+// Inject's output travels.
+func FromTable(table map[string]interface{}) Headers {
+	h := Headers{}
+	for k, v := range table {
+		if s, ok := v.(string); ok {
+			h[k] = s
+		}
+	}
+	return h
+}
+
+// Table converts h into AMQP message headers.
+func (h Headers) Table() map[string]interface{} {
+	table := make(map[string]interface{}, len(h))
+	for k, v := range h {
+		table[k] = v
+	}
+	return table
+}
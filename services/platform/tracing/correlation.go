@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+// Header names used to carry the correlation id over HTTP and AMQP.
+const (
+	RequestIDHeader     = "X-Request-ID"
+	CorrelationIDHeader = "x-correlation-id"
+)
+
+type contextKey int
+
+const correlationIDKey contextKey = iota
+
+// WithCorrelationID returns a context carrying id, the identifier shared by
+// an HTTP request and every event published because of it.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id)
+}
+
+// CorrelationID returns the id stored in ctx, or "" if there is none.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// NewCorrelationID returns a random (version 4) UUID.
+func NewCorrelationID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"product_service/repository"
+	"product_service/usecase"
+)
+
+var errAnnounceUsage = errors.New("usage: announce-products")
+
+// runAnnounce implements the announce-products subcommand. It writes a
+// product.created event for every product to the outbox, where the relay
+// of the running service picks them up. Order Service only learns about
+// products from these events, so run it once when its product_view is
+// empty or behind, e.g. right after it was first deployed.
+func runAnnounce(ctx context.Context, db *sql.DB, args []string) error {
+	if len(args) > 0 {
+		return errAnnounceUsage
+	}
+
+	products := usecase.NewProductUseCase(
+		repository.NewProductPostgres(db),
+		repository.NewCategoryPostgres(db),
+		repository.NewTransactor(db),
+	)
+	n, err := products.AnnounceAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("announced %d product(s)\n", n)
+	return nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+
+	"platform/rabbitmq"
+)
+
+type RabbitMQConfig struct {
+	rabbitmq.Config `yaml:",inline"`
+
+	Queues QueueConfig `yaml:"queues"`
+}
+
+// QueueConfig names the queue each consumer reads from. Replicas of the
+// service share a queue, so they split its messages between them.
+type QueueConfig struct {
+	OrderCreated   string `yaml:"order_created"`
+	OrderCancelled string `yaml:"order_cancelled"`
+}
+
+func (c *RabbitMQConfig) validate() error {
+	if err := c.Config.Validate(); err != nil {
+		return err
+	}
+
+	seen := map[string]string{}
+	for _, q := range []struct{ key, name string }{
+		{"RABBITMQ_QUEUE_ORDER_CREATED", c.Queues.OrderCreated},
+		{"RABBITMQ_QUEUE_ORDER_CANCELLED", c.Queues.OrderCancelled},
+	} {
+		if q.name == "" {
+			return fmt.Errorf("%s must not be empty", q.key)
+		}
+		if other, ok := seen[q.name]; ok {
+			return fmt.Errorf("%s and %s both name queue %q", other, q.key, q.name)
+		}
+		seen[q.name] = q.key
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"platform/problem"
 	"product_service/usecase"
 )
 
@@ -22,13 +23,13 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid request", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "invalid request")
 		return
 	}
 
-	category, err := h.uc.Create(req.Name)
+	category, err := h.uc.Create(r.Context(), req.Name)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -36,9 +37,9 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.uc.GetAll()
+	categories, err := h.uc.GetAll(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -46,15 +47,17 @@ func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
-	idStr := r.PathValue("id")
-	id, _ := strconv.ParseInt(idStr, 10, 64)
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid category id")
+		return
+	}
 
-	category, err := h.uc.GetByID(id)
+	category, err := h.uc.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		problem.Error(w, r, err)
 		return
 	}
 
 	json.NewEncoder(w).Encode(category)
 }
-
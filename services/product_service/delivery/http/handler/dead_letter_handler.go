@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"platform/problem"
+	"platform/rabbitmq"
+	"product_service/domain"
+)
+
+const (
+	defaultDeadLetterLimit = 20
+	maxDeadLetterLimit     = 100
+)
+
+type DeadLetterStore interface {
+	DeadLetters(queue string, limit int) ([]*rabbitmq.DeadLetter, error)
+	ReplayDeadLetters(queue string, limit int) (int, error)
+}
+
+// DeadLetterHandler lets admins look at and replay messages that consumers
+// gave up on. Access is restricted by the route policy.
+type DeadLetterHandler struct {
+	store DeadLetterStore
+}
+
+func NewDeadLetterHandler(store DeadLetterStore) *DeadLetterHandler {
+	return &DeadLetterHandler{store: store}
+}
+
+func (h *DeadLetterHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit, err := deadLetterLimit(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	letters, err := h.store.DeadLetters(r.PathValue("queue"), limit)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(letters)
+}
+
+func (h *DeadLetterHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	limit, err := deadLetterLimit(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	replayed, err := h.store.ReplayDeadLetters(r.PathValue("queue"), limit)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"replayed": replayed})
+}
+
+func deadLetterLimit(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultDeadLetterLimit, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, domain.Invalid("invalid limit")
+	}
+	return min(n, maxDeadLetterLimit), nil
+}
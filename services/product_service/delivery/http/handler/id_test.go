@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestInvalidIDs checks that a malformed path id is rejected before it
+// reaches the use case, which is nil here.
+func TestInvalidIDs(t *testing.T) {
+	products := NewProductHandler(nil)
+	stock := NewStockHandler(nil)
+	categories := NewCategoryHandler(nil)
+
+	tests := []struct {
+		name    string
+		pattern string
+		target  string
+		body    string
+		handle  http.HandlerFunc
+	}{
+		{"get product", "GET /products/{id}", "/products/abc", "", products.GetByID},
+		{"update price", "PUT /products/{id}/price", "/products/abc/price", `{"price": 1}`, products.UpdatePrice},
+		{"products by category", "GET /categories/{category_id}/products", "/categories/abc/products", "", products.GetByCategory},
+		{"get category", "GET /categories/{id}", "/categories/abc", "", categories.GetByID},
+		{"add stock", "POST /products/{id}/stock", "/products/abc/stock", `{"quantity": 1}`, stock.Add},
+		{"get stock", "GET /products/{id}/stock", "/products/abc/stock", "", stock.GetByProductID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc(tt.pattern, tt.handle)
+
+			method, _, _ := strings.Cut(tt.pattern, " ")
+			req := httptest.NewRequest(method, tt.target, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
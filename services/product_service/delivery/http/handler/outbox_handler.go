@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"platform/problem"
+	"product_service/domain"
+)
+
+type OutboxStatsProvider interface {
+	Stats(ctx context.Context) (*domain.OutboxStats, error)
+}
+
+type OutboxHandler struct {
+	stats OutboxStatsProvider
+}
+
+func NewOutboxHandler(stats OutboxStatsProvider) *OutboxHandler {
+	return &OutboxHandler{stats: stats}
+}
+
+// Stats reports how far the outbox relay is behind.
+func (h *OutboxHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.stats.Stats(r.Context())
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
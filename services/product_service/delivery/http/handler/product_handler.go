@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"platform/problem"
 	"product_service/usecase"
 )
 
@@ -25,18 +26,44 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid request", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "invalid request")
 		return
 	}
 
 	product, err := h.uc.Create(
+		r.Context(),
 		req.Name,
 		req.CategoryID,
 		req.Price,
 		req.Stock,
 	)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Error(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(product)
+}
+
+func (h *ProductHandler) UpdatePrice(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid product id")
+		return
+	}
+
+	var req struct {
+		Price float64 `json:"price"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	product, err := h.uc.UpdatePrice(r.Context(), id, req.Price)
+	if err != nil {
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -44,9 +71,9 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	products, err := h.uc.GetAll()
+	products, err := h.uc.GetAll(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -54,12 +81,15 @@ func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
-	idStr := r.PathValue("id")
-	id, _ := strconv.ParseInt(idStr, 10, 64)
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid product id")
+		return
+	}
 
-	product, err := h.uc.GetByID(id)
+	product, err := h.uc.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -67,15 +97,17 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ProductHandler) GetByCategory(w http.ResponseWriter, r *http.Request) {
-	idStr := r.PathValue("category_id")
-	id, _ := strconv.ParseInt(idStr, 10, 64)
+	id, err := strconv.ParseInt(r.PathValue("category_id"), 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid category id")
+		return
+	}
 
-	products, err := h.uc.GetByCategory(id)
+	products, err := h.uc.GetByCategory(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Error(w, r, err)
 		return
 	}
 
 	json.NewEncoder(w).Encode(products)
 }
-
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"platform/problem"
+	"product_service/usecase"
+)
+
+type StockHandler struct {
+	uc usecase.StockUseCase
+}
+
+func NewStockHandler(uc usecase.StockUseCase) *StockHandler {
+	return &StockHandler{uc: uc}
+}
+
+func (h *StockHandler) Add(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid product id")
+		return
+	}
+
+	var req struct {
+		Quantity int `json:"quantity"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	if err := h.uc.Add(r.Context(), id, req.Quantity); err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	stock, err := h.uc.GetByProductID(r.Context(), id)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(stock)
+}
+
+func (h *StockHandler) GetByProductID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid product id")
+		return
+	}
+
+	stock, err := h.uc.GetByProductID(r.Context(), id)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(stock)
+}
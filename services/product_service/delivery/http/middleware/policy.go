@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"platform/problem"
+	"product_service/domain"
+)
+
+// Policy maps a route pattern, exactly as registered on the mux, to the
+// roles allowed to call it. Public routes are listed with Public; patterns
+// missing from the table are denied to everyone.
+type Policy map[string][]domain.Role
+
+// Public lets anyone call a route, with or without a token.
+var Public []domain.Role
+
+type Authorizer struct {
+	verifier TokenVerifier
+	policy   Policy
+}
+
+func NewAuthorizer(verifier TokenVerifier, policy Policy) *Authorizer {
+	return &Authorizer{verifier: verifier, policy: policy}
+}
+
+// Protect wraps h with authentication and role checks for the roles listed
+// for pattern. A Public pattern gets h untouched; an unlisted one is refused,
+// so a route added without a policy entry cannot end up open by mistake.
+func (a *Authorizer) Protect(pattern string, h http.Handler) http.Handler {
+	roles, ok := a.policy[pattern]
+	if !ok {
+		slog.Error("route has no access policy, denying it", "route", pattern)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			problem.Write(w, r, http.StatusForbidden, "route has no access policy")
+		})
+	}
+	if len(roles) == 0 {
+		return h
+	}
+	return Authenticate(a.verifier)(RequireRoles(roles...)(h))
+}
+
+// RequireRoles must run after Authenticate.
+func RequireRoles(roles ...domain.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actor, ok := ActorFromContext(r.Context())
+			if !ok {
+				unauthorized(w, r, "missing bearer token")
+				return
+			}
+			if !actor.HasRole(roles...) {
+				problem.Write(w, r, http.StatusForbidden, "insufficient role")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
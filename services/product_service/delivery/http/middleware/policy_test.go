@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"product_service/domain"
+)
+
+// staticVerifier accepts the tokens it was built with.
+type staticVerifier map[string]domain.Actor
+
+func (v staticVerifier) Verify(token string) (domain.Actor, error) {
+	actor, ok := v[token]
+	if !ok {
+		return domain.Actor{}, errors.New("unknown token")
+	}
+	return actor, nil
+}
+
+func TestProtect(t *testing.T) {
+	authz := NewAuthorizer(staticVerifier{
+		"admin":  {UserID: 1, Role: domain.RoleAdmin},
+		"worker": {UserID: 2, Role: domain.RoleWorker},
+		"client": {UserID: 3, Role: domain.RoleClient},
+	}, Policy{
+		"POST /products": {domain.RoleAdmin, domain.RoleWorker},
+		"GET /products":  Public,
+	})
+
+	tests := []struct {
+		name    string
+		pattern string
+		token   string
+		want    int
+	}{
+		{"admin writes", "POST /products", "admin", http.StatusOK},
+		{"worker writes", "POST /products", "worker", http.StatusOK},
+		{"client cannot write", "POST /products", "client", http.StatusForbidden},
+		{"anonymous cannot write", "POST /products", "", http.StatusUnauthorized},
+		{"invalid token cannot write", "POST /products", "forged", http.StatusUnauthorized},
+		{"public route needs no token", "GET /products", "", http.StatusOK},
+		{"public route ignores a token", "GET /products", "client", http.StatusOK},
+		{"unlisted route is denied", "DELETE /products/{id}", "admin", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := authz.Protect(tt.pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/products", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
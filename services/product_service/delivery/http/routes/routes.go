@@ -4,25 +4,72 @@ import (
 	"net/http"
 
 	"product_service/delivery/http/handler"
+	"product_service/delivery/http/middleware"
+	"product_service/domain"
+	"product_service/metrics"
 )
 
+// CatalogPolicy lists every route and the roles allowed to call it. Reading
+// the catalog and the operational endpoints are public; a route missing here
+// is denied.
+var CatalogPolicy = middleware.Policy{
+	"GET /categories":                        middleware.Public,
+	"GET /categories/{id}":                   middleware.Public,
+	"GET /products":                          middleware.Public,
+	"GET /products/{id}":                     middleware.Public,
+	"GET /categories/{category_id}/products": middleware.Public,
+	"GET /products/{id}/stock":               middleware.Public,
+
+	"GET /outbox/stats": middleware.Public,
+	"GET /metrics":      middleware.Public,
+	"GET /healthz":      middleware.Public,
+	"GET /readyz":       middleware.Public,
+
+	"POST /categories":          {domain.RoleAdmin, domain.RoleWorker},
+	"POST /products":            {domain.RoleAdmin, domain.RoleWorker},
+	"PUT /products/{id}/price":  {domain.RoleAdmin, domain.RoleWorker},
+	"POST /products/{id}/stock": {domain.RoleAdmin, domain.RoleWorker},
+
+	"GET /admin/dlq/{queue}":         {domain.RoleAdmin},
+	"POST /admin/dlq/{queue}/replay": {domain.RoleAdmin},
+}
+
 func Setup(
 	categoryHandler *handler.CategoryHandler,
 	productHandler *handler.ProductHandler,
+	stockHandler *handler.StockHandler,
+	outboxHandler *handler.OutboxHandler,
+	deadLetterHandler *handler.DeadLetterHandler,
+	healthHandler *handler.HealthHandler,
+	authz *middleware.Authorizer,
 ) *http.ServeMux {
 
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("POST /categories", categoryHandler.Create)
-	mux.HandleFunc("GET /categories", categoryHandler.GetAll)
-	mux.HandleFunc("GET /categories/{id}", categoryHandler.GetByID)
+	handle := func(pattern string, h http.HandlerFunc) {
+		mux.Handle(pattern, authz.Protect(pattern, h))
+	}
 
-	
-	mux.HandleFunc("POST /products", productHandler.Create)
-	mux.HandleFunc("GET /products", productHandler.GetAll)
-	mux.HandleFunc("GET /products/{id}", productHandler.GetByID)
-	mux.HandleFunc("GET /categories/{category_id}/products", productHandler.GetByCategory)
+	handle("POST /categories", categoryHandler.Create)
+	handle("GET /categories", categoryHandler.GetAll)
+	handle("GET /categories/{id}", categoryHandler.GetByID)
+
+	handle("POST /products", productHandler.Create)
+	handle("GET /products", productHandler.GetAll)
+	handle("GET /products/{id}", productHandler.GetByID)
+	handle("PUT /products/{id}/price", productHandler.UpdatePrice)
+	handle("GET /categories/{category_id}/products", productHandler.GetByCategory)
+
+	handle("POST /products/{id}/stock", stockHandler.Add)
+	handle("GET /products/{id}/stock", stockHandler.GetByProductID)
+
+	handle("GET /outbox/stats", outboxHandler.Stats)
+	handle("GET /metrics", metrics.Handler().ServeHTTP)
+	handle("GET /healthz", healthHandler.Live)
+	handle("GET /readyz", healthHandler.Ready)
+
+	handle("GET /admin/dlq/{queue}", deadLetterHandler.List)
+	handle("POST /admin/dlq/{queue}/replay", deadLetterHandler.Replay)
 
 	return mux
 }
-
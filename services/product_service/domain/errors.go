@@ -0,0 +1,26 @@
+package domain
+
+import "platform/domainerr"
+
+// Kinds of failure a caller can act on. They are shared by every service;
+// see domainerr.
+var (
+	ErrInvalid      = domainerr.ErrInvalid
+	ErrUnauthorized = domainerr.ErrUnauthorized
+	ErrForbidden    = domainerr.ErrForbidden
+	ErrNotFound     = domainerr.ErrNotFound
+	ErrConflict     = domainerr.ErrConflict
+)
+
+var (
+	ErrCategoryNotFound = &Error{Kind: ErrNotFound, Msg: "category not found"}
+	ErrCategoryExists   = &Error{Kind: ErrConflict, Msg: "category already exists"}
+)
+
+// Error is a domain error whose message is safe to show to the caller.
+type Error = domainerr.Error
+
+// Invalid reports input the caller has to fix.
+func Invalid(msg string) error {
+	return domainerr.Invalid(msg)
+}
@@ -1,5 +1,7 @@
 package domain
 
+var ErrProductNotFound = &Error{Kind: ErrNotFound, Msg: "product not found"}
+
 type Product struct {
 	ID         int64   `json:"id"`
 	Name       string  `json:"name"`
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+type ReservationStatus string
+
+const (
+	ReservationReserved ReservationStatus = "RESERVED"
+	ReservationReleased ReservationStatus = "RELEASED"
+)
+
+// Reservation is one line of the reservations ledger: how much of a product
+// an order currently holds.
+type Reservation struct {
+	OrderID    int64             `json:"order_id"`
+	ProductID  int64             `json:"product_id"`
+	Quantity   int               `json:"quantity"`
+	Status     ReservationStatus `json:"status"`
+	ReservedAt time.Time         `json:"reserved_at"`
+	ReleasedAt *time.Time        `json:"released_at,omitempty"`
+}
@@ -0,0 +1,34 @@
+package domain
+
+// Role mirrors the roles issued by user_service in access tokens.
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleWorker Role = "worker"
+	RoleClient Role = "client"
+)
+
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleWorker, RoleClient:
+		return true
+	default:
+		return false
+	}
+}
+
+// Actor is the authenticated caller of a use case.
+type Actor struct {
+	UserID int64
+	Role   Role
+}
+
+func (a Actor) HasRole(roles ...Role) bool {
+	for _, r := range roles {
+		if a.Role == r {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,45 @@
 package domain
 
-import "errors"
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
 
-var ErrNotEnoughStock = errors.New("not enough stock")
+var ErrNotEnoughStock = &Error{Kind: ErrConflict, Msg: "not enough stock"}
+
+const (
+	ShortfallNotEnoughStock = "not_enough_stock"
+	ShortfallUnknownProduct = "unknown_product"
+	ShortfallInvalidQty     = "invalid_quantity"
+)
+
+// Shortfall describes why one product of an order could not be reserved.
+type Shortfall struct {
+	ProductID int64  `json:"product_id"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+	Reason    string `json:"reason"`
+}
+
+// ReservationError is returned when an order cannot be reserved as a whole.
+// It matches ErrNotEnoughStock with errors.Is.
+type ReservationError struct {
+	Shortfalls []Shortfall
+}
+
+func (e *ReservationError) Error() string {
+	parts := make([]string, 0, len(e.Shortfalls))
+	for _, s := range e.Shortfalls {
+		parts = append(parts, fmt.Sprintf("product %d: %s (requested %d, available %d)",
+			s.ProductID, s.Reason, s.Requested, s.Available))
+	}
+	return "cannot reserve order: " + strings.Join(parts, "; ")
+}
+
+func (e *ReservationError) Unwrap() error {
+	return ErrNotEnoughStock
+}
 
 func (s *Stock) CanReserve(qty int) error {
 	if qty <= 0 {
@@ -22,3 +59,66 @@ func (s *Stock) Reserve(qty int) error {
 	return nil
 }
 
+// Demand sums the requested quantity per product, so an order listing the
+// same product twice is checked against its stock once.
+func Demand(items []OrderItem) map[int64]int {
+	demand := make(map[int64]int, len(items))
+	for _, item := range items {
+		demand[item.ProductID] += item.Quantity
+	}
+	return demand
+}
+
+// SortedProductIDs returns the products of a demand in ascending order, the
+// order rows are locked in to avoid deadlocks between concurrent orders.
+func SortedProductIDs(demand map[int64]int) []int64 {
+	ids := make([]int64, 0, len(demand))
+	for id := range demand {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// CheckReservation reports every product of the order that cannot be
+// reserved from the given stock levels. An empty result means the whole
+// order fits.
+func CheckReservation(stocks map[int64]*Stock, items []OrderItem) []Shortfall {
+	var shortfalls []Shortfall
+
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			shortfalls = append(shortfalls, Shortfall{
+				ProductID: item.ProductID,
+				Requested: item.Quantity,
+				Reason:    ShortfallInvalidQty,
+			})
+		}
+	}
+
+	demand := Demand(items)
+	for _, productID := range SortedProductIDs(demand) {
+		requested := demand[productID]
+
+		stock, ok := stocks[productID]
+		if !ok {
+			shortfalls = append(shortfalls, Shortfall{
+				ProductID: productID,
+				Requested: requested,
+				Reason:    ShortfallUnknownProduct,
+			})
+			continue
+		}
+
+		if requested > 0 && stock.Quantity < requested {
+			shortfalls = append(shortfalls, Shortfall{
+				ProductID: productID,
+				Requested: requested,
+				Available: stock.Quantity,
+				Reason:    ShortfallNotEnoughStock,
+			})
+		}
+	}
+
+	return shortfalls
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCheckReservation(t *testing.T) {
+	stocks := map[int64]*Stock{
+		1: {ProductID: 1, Quantity: 5},
+		2: {ProductID: 2, Quantity: 0},
+		3: {ProductID: 3, Quantity: 10},
+	}
+
+	tests := []struct {
+		name  string
+		items []OrderItem
+		want  []Shortfall
+	}{
+		{
+			name:  "fits",
+			items: []OrderItem{{ProductID: 1, Quantity: 5}, {ProductID: 3, Quantity: 1}},
+		},
+		{
+			name:  "no items",
+			items: nil,
+		},
+		{
+			name:  "not enough stock",
+			items: []OrderItem{{ProductID: 1, Quantity: 6}},
+			want:  []Shortfall{{ProductID: 1, Requested: 6, Available: 5, Reason: ShortfallNotEnoughStock}},
+		},
+		{
+			name:  "out of stock",
+			items: []OrderItem{{ProductID: 2, Quantity: 1}},
+			want:  []Shortfall{{ProductID: 2, Requested: 1, Available: 0, Reason: ShortfallNotEnoughStock}},
+		},
+		{
+			name:  "repeated product is summed",
+			items: []OrderItem{{ProductID: 1, Quantity: 3}, {ProductID: 1, Quantity: 3}},
+			want:  []Shortfall{{ProductID: 1, Requested: 6, Available: 5, Reason: ShortfallNotEnoughStock}},
+		},
+		{
+			name:  "unknown product",
+			items: []OrderItem{{ProductID: 9, Quantity: 1}, {ProductID: 9, Quantity: 2}},
+			want:  []Shortfall{{ProductID: 9, Requested: 3, Reason: ShortfallUnknownProduct}},
+		},
+		{
+			name:  "invalid quantities come first, in item order",
+			items: []OrderItem{{ProductID: 3, Quantity: 0}, {ProductID: 1, Quantity: -1}},
+			want: []Shortfall{
+				{ProductID: 3, Requested: 0, Reason: ShortfallInvalidQty},
+				{ProductID: 1, Requested: -1, Reason: ShortfallInvalidQty},
+			},
+		},
+		{
+			name: "every shortfall in product order",
+			items: []OrderItem{
+				{ProductID: 9, Quantity: 1},
+				{ProductID: 3, Quantity: 2},
+				{ProductID: 2, Quantity: 4},
+				{ProductID: 1, Quantity: 0},
+				{ProductID: 1, Quantity: 7},
+			},
+			want: []Shortfall{
+				{ProductID: 1, Requested: 0, Reason: ShortfallInvalidQty},
+				{ProductID: 1, Requested: 7, Available: 5, Reason: ShortfallNotEnoughStock},
+				{ProductID: 2, Requested: 4, Available: 0, Reason: ShortfallNotEnoughStock},
+				{ProductID: 9, Requested: 1, Reason: ShortfallUnknownProduct},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckReservation(stocks, tt.items)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReservationErrorMatchesNotEnoughStock(t *testing.T) {
+	var err error = &ReservationError{Shortfalls: []Shortfall{{ProductID: 1, Requested: 2, Available: 1, Reason: ShortfallNotEnoughStock}}}
+	if !errors.Is(err, ErrNotEnoughStock) {
+		t.Errorf("%v does not match ErrNotEnoughStock", err)
+	}
+}
+
+func TestStockReserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		qty      int
+		want     error
+		wantLeft int
+	}{
+		{"part", 2, nil, 3},
+		{"all", 5, nil, 0},
+		{"too many", 6, ErrNotEnoughStock, 5},
+		{"zero", 0, ErrNotEnoughStock, 5},
+		{"negative", -1, ErrNotEnoughStock, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stock := &Stock{ProductID: 1, Quantity: 5}
+			if err := stock.Reserve(tt.qty); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if stock.Quantity != tt.wantLeft {
+				t.Errorf("quantity = %d, want %d", stock.Quantity, tt.wantLeft)
+			}
+		})
+	}
+}
@@ -1,66 +1,122 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
 	"net/http"
 	"os"
-	"strings"
-
+	"os/signal"
+	"syscall"
+	"time"
+
+	"platform/health"
+	"platform/httpmw"
+	"platform/logging"
+	"platform/postgres"
+	"platform/rabbitmq"
+	"platform/tracing"
+	"product_service/auth"
 	"product_service/config"
 	"product_service/delivery/http/handler"
+	"product_service/delivery/http/middleware"
 	"product_service/delivery/http/routes"
+	"product_service/domain"
 	"product_service/messaging"
+	"product_service/metrics"
+	"product_service/migrations"
 	"product_service/repository"
 	"product_service/usecase"
-
-	"github.com/streadway/amqp"
 )
 
+// shutdownTimeout bounds draining requests, messages and the outbox relay
+// on SIGTERM. It stays below the grace period Docker Compose allows before
+// it kills the container.
+const shutdownTimeout = 20 * time.Second
+
+// requestTimeout is the deadline of every HTTP request.
+const requestTimeout = 10 * time.Second
+
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// -------------------------
-	// Database
+	// Config
 	// -------------------------
-	db, err := config.ConnectDB()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatal("invalid config", logging.Err(err))
+	}
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			logging.Fatal("failed to print config", logging.Err(err))
+		}
+		return
+	}
+	if len(cfg.Args) > 0 && cfg.Args[0] != "migrate" && cfg.Args[0] != "announce-products" {
+		logging.Fatal("unknown command", "command", cfg.Args[0])
 	}
-	defer db.Close()
 
 	// -------------------------
-	// RabbitMQ
+	// Logging
 	// -------------------------
-	rabbitURL := os.Getenv("RABBITMQ_URL")
-	if rabbitURL == "" {
-		log.Fatal("RABBITMQ_URL is not set")
-	}
-	if !strings.HasPrefix(rabbitURL, "amqp://") && !strings.HasPrefix(rabbitURL, "amqps://") {
-		log.Fatal("invalid RABBITMQ_URL: " + rabbitURL)
+	if err := logging.Setup(cfg.Log.Level, domain.ServiceName); err != nil {
+		logging.Fatal("invalid LOG_LEVEL", logging.Err(err))
 	}
 
-	conn, err := amqp.Dial(rabbitURL)
+	// -------------------------
+	// Tracing
+	// -------------------------
+	shutdownTracing, err := tracing.Setup(context.Background(), domain.ServiceName)
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatal("failed to set up tracing", logging.Err(err))
 	}
-	defer conn.Close()
+	defer shutdownTracing(context.Background())
 
-	ch, err := conn.Channel()
+	// -------------------------
+	// Database
+	// -------------------------
+	db, err := postgres.Connect(ctx, &cfg.DB)
 	if err != nil {
-		log.Fatal(err)
-	}
-	defer ch.Close()
-
-	err = ch.ExchangeDeclare(
-		"events",
-		"topic",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+		logging.Fatal("failed to connect to database", logging.Err(err))
+	}
+	defer db.Close()
+
+	// -------------------------
+	// Migrations
+	// -------------------------
+	if len(cfg.Args) > 0 && cfg.Args[0] == "migrate" {
+		if err := runMigrate(ctx, db, cfg.Args[1:]); err != nil {
+			logging.Fatal("migrate failed", logging.Err(err))
+		}
+		return
+	}
+	if cfg.DB.MigrateOnStart {
+		if _, err := migrations.Migrator.Up(ctx, db); err != nil {
+			logging.Fatal("failed to migrate database", logging.Err(err))
+		}
+	}
+	if len(cfg.Args) > 0 {
+		if err := runAnnounce(ctx, db, cfg.Args[1:]); err != nil {
+			logging.Fatal("announce-products failed", logging.Err(err))
+		}
+		return
+	}
+
+	// -------------------------
+	// RabbitMQ
+	// -------------------------
+	// The connection redials on its own if the broker goes away.
+	conn, err := rabbitmq.Dial(cfg.RabbitMQ.URL, cfg.RabbitMQ.Exchange)
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatal("failed to connect to rabbitmq", logging.Err(err))
 	}
+	defer conn.Close()
 
 	// -------------------------
 	// Repositories
@@ -68,6 +124,7 @@ func main() {
 	categoryRepo := repository.NewCategoryPostgres(db)
 	productRepo  := repository.NewProductPostgres(db)
 	stockRepo    := repository.NewStockPostgres(db)
+	transactor   := repository.NewTransactor(db)
 
 	// -------------------------
 	// UseCases
@@ -76,35 +133,110 @@ func main() {
 	productUC := usecase.NewProductUseCase(
 		productRepo,
 		categoryRepo,
-		stockRepo,
+		transactor,
 	)
-	stockUC := usecase.NewStockUseCase(stockRepo)
+	stockUC := usecase.NewStockUseCase(stockRepo, transactor)
 
 	// -------------------------
 	// Rabbit Publisher
 	// -------------------------
-	publisher := messaging.NewPublisher(ch)
+	publisher := messaging.NewPublisher(conn)
+
+	// -------------------------
+	// Outbox relay
+	// -------------------------
+	relay := messaging.NewOutboxRelay(transactor, repository.NewOutboxPostgres(db), publisher, cfg.RabbitMQ.Exchange)
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(relayCtx)
+	}()
 
 	// -------------------------
 	// Rabbit Consumers
 	// -------------------------
-	if err := messaging.ConsumeOrderCreated(
-		ch,
-		stockUC,
-		publisher,
-	); err != nil {
-		log.Fatal(err)
+	runner := rabbitmq.NewConsumerRunner(conn)
+
+	// order.created → reserve stock
+	messaging.ConsumeOrderCreated(runner, cfg.RabbitMQ.Queues.OrderCreated, stockUC)
+
+	// order.cancelled → release reserved stock
+	messaging.ConsumeOrderCancelled(runner, cfg.RabbitMQ.Queues.OrderCancelled, stockUC)
+
+	if err := runner.Start(); err != nil {
+		logging.Fatal("failed to start consumers", logging.Err(err))
 	}
 
+	// -------------------------
+	// Metrics
+	// -------------------------
+	metrics.RegisterDB(db)
+	metrics.RegisterOutbox(relay.Stats)
+	metrics.RegisterQueues(ctx, runner.QueueDepths)
+
 	// -------------------------
 	// HTTP Handlers
 	// -------------------------
 	categoryHandler := handler.NewCategoryHandler(categoryUC)
 	productHandler := handler.NewProductHandler(productUC)
+	stockHandler := handler.NewStockHandler(stockUC)
+	outboxHandler := handler.NewOutboxHandler(relay)
+	deadLetterHandler := handler.NewDeadLetterHandler(runner)
+	healthHandler := handler.NewHealthHandler(
+		health.Checks{"consumers": runner.Live},
+		health.Checks{
+			"postgres":  db.PingContext,
+			"rabbitmq":  conn.Check,
+			"consumers": runner.Alive,
+		},
+	)
+
+	verifier := auth.NewVerifier(cfg.Auth.JWKSURL, cfg.Auth.Issuer)
+	authz := middleware.NewAuthorizer(verifier, routes.CatalogPolicy)
 
-	router := routes.Setup(categoryHandler, productHandler)
+	router := routes.Setup(categoryHandler, productHandler, stockHandler, outboxHandler, deadLetterHandler, healthHandler, authz)
+
+	httpHandler := middleware.Timeout(requestTimeout)(
+		httpmw.Trace(httpmw.AccessLog(middleware.Metrics(router))),
+	)
+	server := &http.Server{Addr: cfg.HTTP.Addr, Handler: httpHandler}
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Product Service running", "addr", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err := <-serverErr:
+		logging.Fatal("http server stopped", logging.Err(err))
+	}
+
+	// -------------------------
+	// Shutdown
+	// -------------------------
+	// Requests first, then messages, then the relay, so the events they
+	// enqueue still go out. Deferred calls then close the RabbitMQ
+	// connection and the database and flush traces.
+	stop() // a second signal kills the process right away
+	slog.Info("shutting down", "timeout", shutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("http requests not drained", logging.Err(err))
+	}
+	if err := runner.Stop(shutdownCtx); err != nil {
+		slog.Error("messages not drained", logging.Err(err))
+	}
+	stopRelay()
+	select {
+	case <-relayDone:
+	case <-shutdownCtx.Done():
+		slog.Error("outbox relay not stopped", logging.Err(shutdownCtx.Err()))
+	}
 
-	log.Println("Product Service running on :8082")
-	log.Fatal(http.ListenAndServe(":8082", router))
+	slog.Info("Product Service stopped")
 }
 
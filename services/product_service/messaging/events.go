@@ -1,19 +1,23 @@
 package messaging
 
-import "product_service/domain"
+import (
+	"events"
+	"platform/rabbitmq"
 
-type OrderCreatedEvent struct {
-	OrderID int64              `json:"order_id"`
-	Items   []domain.OrderItem `json:"items"`
-}
-
-type InventoryReservedEvent struct {
-	OrderID int64              `json:"order_id"`
-	Items   []domain.OrderItem `json:"items"`
-}
+	"github.com/streadway/amqp"
+)
 
-type InventoryFailedEvent struct {
-	OrderID int64 `json:"order_id"`
-	Reason  string `json:"reason"`
+// decodeEvent validates msg against the schema of its event type and
+// version and decodes the payload as the given version of the event into v.
+// Failures are permanent: redelivering a malformed message, or one newer
+// than this service understands, cannot succeed until someone intervenes.
+func decodeEvent(msg amqp.Delivery, version int, v interface{}) error {
+	env, err := events.Decode(rabbitmq.OriginalRoutingKey(msg), msg.MessageId, msg.Body)
+	if err != nil {
+		return rabbitmq.Permanent(err)
+	}
+	if err := env.DataAs(version, v); err != nil {
+		return rabbitmq.Permanent(err)
+	}
+	return nil
 }
-
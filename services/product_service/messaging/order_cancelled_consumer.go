@@ -0,0 +1,30 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+
+	"platform/rabbitmq"
+
+	"events"
+	"github.com/streadway/amqp"
+	"product_service/usecase"
+)
+
+func ConsumeOrderCancelled(
+	runner *rabbitmq.ConsumerRunner,
+	queue string,
+	stockUC usecase.StockUseCase,
+) {
+	runner.Register(queue, events.OrderCancelled, func(ctx context.Context, msg amqp.Delivery) error {
+		var event events.OrderCancelledV1
+		if err := decodeEvent(msg, 1, &event); err != nil {
+			return err
+		}
+
+		if err := stockUC.ReleaseForOrder(ctx, msg.MessageId, event.OrderID); err != nil {
+			return fmt.Errorf("failed to release stock for order %d: %w", event.OrderID, err)
+		}
+		return nil
+	})
+}
@@ -1,85 +1,40 @@
 package messaging
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"fmt"
 
+	"platform/rabbitmq"
+
+	"events"
 	"github.com/streadway/amqp"
+	"product_service/domain"
 	"product_service/usecase"
 )
 
 func ConsumeOrderCreated(
-	ch *amqp.Channel,
+	runner *rabbitmq.ConsumerRunner,
+	queue string,
 	stockUC usecase.StockUseCase,
-	publisher *Publisher,
-) error {
-
-	q, err := ch.QueueDeclare(
-		"order_created_queue",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		return err
-	}
-
-	err = ch.QueueBind(
-		q.Name,
-		"order.created",
-		"events",
-		false,
-		nil,
-	)
-	if err != nil {
-		return err
-	}
-
-	msgs, err := ch.Consume(
-		q.Name,
-		"",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		return err
-	}
-
-	go func() {
-		for msg := range msgs {
-			var event OrderCreatedEvent
-			if err := json.Unmarshal(msg.Body, &event); err != nil {
-				log.Println("invalid order.created:", err)
-				continue
-			}
-
-			err := stockUC.ReserveForOrder(event.Items)
-			if err != nil {
-				fail := InventoryFailedEvent{
-					OrderID: event.OrderID,
-					Reason:  err.Error(),
-				}
-
-				data, _ := json.Marshal(fail)
-				_ = publisher.Publish("inventory.failed", data)
-				continue
-			}
-
-			ok := InventoryReservedEvent{
-				OrderID: event.OrderID,
-				Items:   event.Items,
-			}
+) {
+	runner.Register(queue, events.OrderCreated, func(ctx context.Context, msg amqp.Delivery) error {
+		var event events.OrderCreatedV1
+		if err := decodeEvent(msg, 1, &event); err != nil {
+			return err
+		}
 
-			data, _ := json.Marshal(ok)
-			_ = publisher.Publish("inventory.reserved", data)
+		items := make([]domain.OrderItem, 0, len(event.Items))
+		for _, item := range event.Items {
+			items = append(items, domain.OrderItem{
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+			})
 		}
-	}()
 
-	return nil
+		// The outcome event is written to the outbox by the use case.
+		if err := stockUC.ReserveForOrder(ctx, msg.MessageId, event.OrderID, items); err != nil {
+			return fmt.Errorf("inventory reservation failed for order %d: %w", event.OrderID, err)
+		}
+		return nil
+	})
 }
-
@@ -0,0 +1,25 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Outcomes of reserving stock for an order.
+const (
+	ReservationReserved = "reserved"
+	ReservationRejected = "rejected"
+)
+
+var (
+	Reservations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "stock_reservations_total",
+		Help: "Orders whose stock was reserved or rejected.",
+	}, []string{"outcome"})
+
+	InventoryFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "inventory_failed_total",
+		Help: "inventory.failed events recorded, by shortfall reason. An order short for several reasons counts once per reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	Registry.MustRegister(Reservations, InventoryFailures)
+}
@@ -19,4 +19,3 @@ CREATE TABLE IF NOT EXISTS stock (
         REFERENCES products(id)
         ON DELETE CASCADE
 );
-
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    order_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    status TEXT NOT NULL,
+    reserved_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    released_at TIMESTAMP,
+    PRIMARY KEY (order_id, product_id),
+    CONSTRAINT fk_reservation_product FOREIGN KEY (product_id)
+        REFERENCES products(id)
+);
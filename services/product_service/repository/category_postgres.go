@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"platform/tracing"
 	"product_service/domain"
 )
 
@@ -13,8 +15,11 @@ func NewCategoryPostgres(db *sql.DB) CategoryRepository {
 	return &categoryPostgres{db: db}
 }
 
-func (r *categoryPostgres) Create(category *domain.Category) error {
-	return r.db.QueryRow(
+func (r *categoryPostgres) Create(ctx context.Context, category *domain.Category) error {
+	_, span := tracing.Start(ctx, "CategoryRepository.Create")
+	defer span.End()
+
+	return r.db.QueryRowContext(ctx,
 		`INSERT INTO categories (name)
 		 VALUES ($1)
 		 RETURNING id`,
@@ -22,8 +27,11 @@ func (r *categoryPostgres) Create(category *domain.Category) error {
 	).Scan(&category.ID)
 }
 
-func (r *categoryPostgres) GetAll() ([]*domain.Category, error) {
-	rows, err := r.db.Query(
+func (r *categoryPostgres) GetAll(ctx context.Context) ([]*domain.Category, error) {
+	_, span := tracing.Start(ctx, "CategoryRepository.GetAll")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx,
 		`SELECT id, name FROM categories ORDER BY name`,
 	)
 	if err != nil {
@@ -42,34 +50,45 @@ func (r *categoryPostgres) GetAll() ([]*domain.Category, error) {
 	return categories, nil
 }
 
-func (r *categoryPostgres) GetByID(id int64) (*domain.Category, error) {
-	row := r.db.QueryRow(
+func (r *categoryPostgres) GetByID(ctx context.Context, id int64) (*domain.Category, error) {
+	_, span := tracing.Start(ctx, "CategoryRepository.GetByID")
+	defer span.End()
+
+	row := r.db.QueryRowContext(ctx,
 		`SELECT id, name FROM categories WHERE id = $1`,
 		id,
 	)
 
 	var c domain.Category
 	if err := row.Scan(&c.ID, &c.Name); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrCategoryNotFound
+		}
 		return nil, err
 	}
 	return &c, nil
 }
 
-func (r *categoryPostgres) ExistsByID(id int64) (bool, error) {
+func (r *categoryPostgres) ExistsByID(ctx context.Context, id int64) (bool, error) {
+	_, span := tracing.Start(ctx, "CategoryRepository.ExistsByID")
+	defer span.End()
+
 	var exists bool
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		`SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1)`,
 		id,
 	).Scan(&exists)
 	return exists, err
 }
 
-func (r *categoryPostgres) ExistsByName(name string) (bool, error) {
+func (r *categoryPostgres) ExistsByName(ctx context.Context, name string) (bool, error) {
+	_, span := tracing.Start(ctx, "CategoryRepository.ExistsByName")
+	defer span.End()
+
 	var exists bool
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		`SELECT EXISTS (SELECT 1 FROM categories WHERE name = $1)`,
 		name,
 	).Scan(&exists)
 	return exists, err
 }
-
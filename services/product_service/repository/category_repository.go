@@ -1,13 +1,15 @@
 package repository
 
-import "product_service/domain"
+import (
+	"context"
+	"product_service/domain"
+)
 
 type CategoryRepository interface {
-	Create(category *domain.Category) error
-	GetAll() ([]*domain.Category, error)
-	GetByID(id int64) (*domain.Category, error)
+	Create(ctx context.Context, category *domain.Category) error
+	GetAll(ctx context.Context) ([]*domain.Category, error)
+	GetByID(ctx context.Context, id int64) (*domain.Category, error)
 
-	ExistsByID(id int64) (bool, error)
-	ExistsByName(name string) (bool, error)
+	ExistsByID(ctx context.Context, id int64) (bool, error)
+	ExistsByName(ctx context.Context, name string) (bool, error)
 }
-
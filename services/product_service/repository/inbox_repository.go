@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+type InboxRepository interface {
+	// MarkProcessed records that consumer handled messageID and reports
+	// whether this is the first time it has been seen. It is meant to run in
+	// the same transaction as the consumer's side effect.
+	MarkProcessed(ctx context.Context, consumer, messageID string) (bool, error)
+}
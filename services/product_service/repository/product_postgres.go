@@ -1,20 +1,25 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"platform/tracing"
 	"product_service/domain"
 )
 
 type productPostgres struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewProductPostgres(db *sql.DB) ProductRepository {
+func NewProductPostgres(db DBTX) ProductRepository {
 	return &productPostgres{db: db}
 }
 
-func (r *productPostgres) Create(product *domain.Product) error {
-	return r.db.QueryRow(
+func (r *productPostgres) Create(ctx context.Context, product *domain.Product) error {
+	_, span := tracing.Start(ctx, "ProductRepository.Create")
+	defer span.End()
+
+	return r.db.QueryRowContext(ctx,
 		`INSERT INTO products (name, category_id, price)
 		 VALUES ($1, $2, $3)
 		 RETURNING id`,
@@ -24,8 +29,11 @@ func (r *productPostgres) Create(product *domain.Product) error {
 	).Scan(&product.ID)
 }
 
-func (r *productPostgres) GetByID(id int64) (*domain.Product, error) {
-	row := r.db.QueryRow(
+func (r *productPostgres) GetByID(ctx context.Context, id int64) (*domain.Product, error) {
+	_, span := tracing.Start(ctx, "ProductRepository.GetByID")
+	defer span.End()
+
+	row := r.db.QueryRowContext(ctx,
 		`SELECT id, name, category_id, price
 		 FROM products WHERE id = $1`,
 		id,
@@ -33,13 +41,53 @@ func (r *productPostgres) GetByID(id int64) (*domain.Product, error) {
 
 	var p domain.Product
 	if err := row.Scan(&p.ID, &p.Name, &p.CategoryID, &p.Price); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrProductNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// LockByID reads a product FOR UPDATE; use it inside a transaction.
+func (r *productPostgres) LockByID(ctx context.Context, id int64) (*domain.Product, error) {
+	_, span := tracing.Start(ctx, "ProductRepository.LockByID")
+	defer span.End()
+
+	row := r.db.QueryRowContext(ctx,
+		`SELECT id, name, category_id, price
+		 FROM products WHERE id = $1
+		 FOR UPDATE`,
+		id,
+	)
+
+	var p domain.Product
+	if err := row.Scan(&p.ID, &p.Name, &p.CategoryID, &p.Price); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrProductNotFound
+		}
 		return nil, err
 	}
 	return &p, nil
 }
 
-func (r *productPostgres) GetAll() ([]*domain.Product, error) {
-	rows, err := r.db.Query(
+func (r *productPostgres) UpdatePrice(ctx context.Context, id int64, price float64) error {
+	_, span := tracing.Start(ctx, "ProductRepository.UpdatePrice")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE products SET price = $1 WHERE id = $2`,
+		price,
+		id,
+	)
+	return err
+}
+
+func (r *productPostgres) GetAll(ctx context.Context) ([]*domain.Product, error) {
+	_, span := tracing.Start(ctx, "ProductRepository.GetAll")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx,
 		`SELECT id, name, category_id, price
 		 FROM products ORDER BY id DESC`,
 	)
@@ -59,8 +107,11 @@ func (r *productPostgres) GetAll() ([]*domain.Product, error) {
 	return products, nil
 }
 
-func (r *productPostgres) GetByCategory(categoryID int64) ([]*domain.Product, error) {
-	rows, err := r.db.Query(
+func (r *productPostgres) GetByCategory(ctx context.Context, categoryID int64) ([]*domain.Product, error) {
+	_, span := tracing.Start(ctx, "ProductRepository.GetByCategory")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx,
 		`SELECT id, name, category_id, price
 		 FROM products
 		 WHERE category_id = $1
@@ -82,4 +133,3 @@ func (r *productPostgres) GetByCategory(categoryID int64) ([]*domain.Product, er
 	}
 	return products, nil
 }
-
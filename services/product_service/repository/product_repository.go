@@ -1,11 +1,15 @@
 package repository
 
-import "product_service/domain"
+import (
+	"context"
+	"product_service/domain"
+)
 
 type ProductRepository interface {
-	Create(product *domain.Product) error
-	GetByID(id int64) (*domain.Product, error)
-	GetAll() ([]*domain.Product, error)
-	GetByCategory(categoryID int64) ([]*domain.Product, error)
+	Create(ctx context.Context, product *domain.Product) error
+	GetByID(ctx context.Context, id int64) (*domain.Product, error)
+	LockByID(ctx context.Context, id int64) (*domain.Product, error)
+	UpdatePrice(ctx context.Context, id int64, price float64) error
+	GetAll(ctx context.Context) ([]*domain.Product, error)
+	GetByCategory(ctx context.Context, categoryID int64) ([]*domain.Product, error)
 }
-
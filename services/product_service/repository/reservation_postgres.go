@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"platform/tracing"
+	"product_service/domain"
+)
+
+type reservationPostgres struct {
+	db DBTX
+}
+
+func NewReservationPostgres(db DBTX) ReservationRepository {
+	return &reservationPostgres{db: db}
+}
+
+func (r *reservationPostgres) Create(ctx context.Context, reservation *domain.Reservation) error {
+	_, span := tracing.Start(ctx, "ReservationRepository.Create")
+	defer span.End()
+
+	if reservation.ReservedAt.IsZero() {
+		reservation.ReservedAt = time.Now()
+	}
+	if reservation.Status == "" {
+		reservation.Status = domain.ReservationReserved
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO stock_reservations (order_id, product_id, quantity, status, reserved_at)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		reservation.OrderID,
+		reservation.ProductID,
+		reservation.Quantity,
+		reservation.Status,
+		reservation.ReservedAt,
+	)
+	return err
+}
+
+func (r *reservationPostgres) LockActiveByOrder(ctx context.Context, orderID int64) ([]*domain.Reservation, error) {
+	_, span := tracing.Start(ctx, "ReservationRepository.LockActiveByOrder")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT order_id, product_id, quantity, status, reserved_at, released_at
+		 FROM stock_reservations
+		 WHERE order_id = $1 AND status = $2
+		 ORDER BY product_id
+		 FOR UPDATE`,
+		orderID,
+		domain.ReservationReserved,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []*domain.Reservation
+	for rows.Next() {
+		var res domain.Reservation
+		var releasedAt sql.NullTime
+		if err := rows.Scan(
+			&res.OrderID,
+			&res.ProductID,
+			&res.Quantity,
+			&res.Status,
+			&res.ReservedAt,
+			&releasedAt,
+		); err != nil {
+			return nil, err
+		}
+		if releasedAt.Valid {
+			res.ReleasedAt = &releasedAt.Time
+		}
+		reservations = append(reservations, &res)
+	}
+	return reservations, rows.Err()
+}
+
+func (r *reservationPostgres) MarkReleased(ctx context.Context, orderID int64) error {
+	_, span := tracing.Start(ctx, "ReservationRepository.MarkReleased")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE stock_reservations
+		 SET status = $1, released_at = $2
+		 WHERE order_id = $3 AND status = $4`,
+		domain.ReservationReleased,
+		time.Now(),
+		orderID,
+		domain.ReservationReserved,
+	)
+	return err
+}
+
+func (r *reservationPostgres) ExistsForOrder(ctx context.Context, orderID int64) (bool, error) {
+	_, span := tracing.Start(ctx, "ReservationRepository.ExistsForOrder")
+	defer span.End()
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM stock_reservations WHERE order_id = $1)`,
+		orderID,
+	).Scan(&exists)
+	return exists, err
+}
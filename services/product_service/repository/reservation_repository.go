@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"product_service/domain"
+)
+
+type ReservationRepository interface {
+	Create(ctx context.Context, reservation *domain.Reservation) error
+	// LockActiveByOrder returns the RESERVED lines of an order FOR UPDATE.
+	LockActiveByOrder(ctx context.Context, orderID int64) ([]*domain.Reservation, error)
+	MarkReleased(ctx context.Context, orderID int64) error
+	ExistsForOrder(ctx context.Context, orderID int64) (bool, error)
+}
@@ -1,20 +1,27 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"platform/tracing"
 	"product_service/domain"
+
+	"github.com/lib/pq"
 )
 
 type stockPostgres struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewStockPostgres(db *sql.DB) StockRepository {
+func NewStockPostgres(db DBTX) StockRepository {
 	return &stockPostgres{db: db}
 }
 
-func (r *stockPostgres) Create(stock *domain.Stock) error {
-	_, err := r.db.Exec(
+func (r *stockPostgres) Create(ctx context.Context, stock *domain.Stock) error {
+	_, span := tracing.Start(ctx, "StockRepository.Create")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx,
 		`INSERT INTO stock (product_id, quantity)
 		 VALUES ($1, $2)`,
 		stock.ProductID,
@@ -23,8 +30,11 @@ func (r *stockPostgres) Create(stock *domain.Stock) error {
 	return err
 }
 
-func (r *stockPostgres) GetByProductID(productID int64) (*domain.Stock, error) {
-	row := r.db.QueryRow(
+func (r *stockPostgres) GetByProductID(ctx context.Context, productID int64) (*domain.Stock, error) {
+	_, span := tracing.Start(ctx, "StockRepository.GetByProductID")
+	defer span.End()
+
+	row := r.db.QueryRowContext(ctx,
 		`SELECT product_id, quantity
 		 FROM stock WHERE product_id = $1`,
 		productID,
@@ -32,13 +42,19 @@ func (r *stockPostgres) GetByProductID(productID int64) (*domain.Stock, error) {
 
 	var s domain.Stock
 	if err := row.Scan(&s.ProductID, &s.Quantity); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrProductNotFound
+		}
 		return nil, err
 	}
 	return &s, nil
 }
 
-func (r *stockPostgres) Update(stock *domain.Stock) error {
-	_, err := r.db.Exec(
+func (r *stockPostgres) Update(ctx context.Context, stock *domain.Stock) error {
+	_, span := tracing.Start(ctx, "StockRepository.Update")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx,
 		`UPDATE stock
 		 SET quantity = $1
 		 WHERE product_id = $2`,
@@ -48,8 +64,30 @@ func (r *stockPostgres) Update(stock *domain.Stock) error {
 	return err
 }
 
-func (r *stockPostgres) Reserve(productID int64, qty int) (bool, error) {
-	res, err := r.db.Exec(
+func (r *stockPostgres) Increase(ctx context.Context, productID int64, qty int) (int, error) {
+	_, span := tracing.Start(ctx, "StockRepository.Increase")
+	defer span.End()
+
+	var quantity int
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE stock
+		 SET quantity = quantity + $1
+		 WHERE product_id = $2
+		 RETURNING quantity`,
+		qty,
+		productID,
+	).Scan(&quantity)
+	if err == sql.ErrNoRows {
+		return 0, domain.ErrProductNotFound
+	}
+	return quantity, err
+}
+
+func (r *stockPostgres) Reserve(ctx context.Context, productID int64, qty int) (bool, error) {
+	_, span := tracing.Start(ctx, "StockRepository.Reserve")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx,
 		`UPDATE stock
 		 SET quantity = quantity - $1
 		 WHERE product_id = $2
@@ -69,3 +107,44 @@ func (r *stockPostgres) Reserve(productID int64, qty int) (bool, error) {
 	return rows == 1, nil
 }
 
+func (r *stockPostgres) Release(ctx context.Context, productID int64, qty int) error {
+	_, span := tracing.Start(ctx, "StockRepository.Release")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE stock
+		 SET quantity = quantity + $1
+		 WHERE product_id = $2`,
+		qty,
+		productID,
+	)
+	return err
+}
+
+func (r *stockPostgres) LockByProductIDs(ctx context.Context, productIDs []int64) (map[int64]*domain.Stock, error) {
+	_, span := tracing.Start(ctx, "StockRepository.LockByProductIDs")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT product_id, quantity
+		 FROM stock
+		 WHERE product_id = ANY($1)
+		 ORDER BY product_id
+		 FOR UPDATE`,
+		pq.Array(productIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make(map[int64]*domain.Stock, len(productIDs))
+	for rows.Next() {
+		var s domain.Stock
+		if err := rows.Scan(&s.ProductID, &s.Quantity); err != nil {
+			return nil, err
+		}
+		stocks[s.ProductID] = &s
+	}
+	return stocks, rows.Err()
+}
@@ -1,11 +1,20 @@
 package repository
 
-import "product_service/domain"
+import (
+	"context"
+	"product_service/domain"
+)
 
 type StockRepository interface {
-	Create(stock *domain.Stock) error
-	GetByProductID(productID int64) (*domain.Stock, error)
-	Update(stock *domain.Stock) error
-	Reserve(productID int64, qty int) (bool, error)
+	Create(ctx context.Context, stock *domain.Stock) error
+	GetByProductID(ctx context.Context, productID int64) (*domain.Stock, error)
+	Update(ctx context.Context, stock *domain.Stock) error
+	// Increase adds qty to the stock of a product in a single statement and
+	// returns the new quantity, so it cannot undo a concurrent reservation.
+	Increase(ctx context.Context, productID int64, qty int) (int, error)
+	Reserve(ctx context.Context, productID int64, qty int) (bool, error)
+	Release(ctx context.Context, productID int64, qty int) error
+	// LockByProductIDs selects the stock rows FOR UPDATE in product id order.
+	// It only makes sense inside a transaction.
+	LockByProductIDs(ctx context.Context, productIDs []int64) (map[int64]*domain.Stock, error)
 }
-
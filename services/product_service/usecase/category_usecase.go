@@ -1,10 +1,12 @@
 package usecase
 
-import "product_service/domain"
+import (
+	"context"
+	"product_service/domain"
+)
 
 type CategoryUseCase interface {
-	Create(name string) (*domain.Category, error)
-	GetAll() ([]*domain.Category, error)
-	GetByID(id int64) (*domain.Category, error)
+	Create(ctx context.Context, name string) (*domain.Category, error)
+	GetAll(ctx context.Context) ([]*domain.Category, error)
+	GetByID(ctx context.Context, id int64) (*domain.Category, error)
 }
-
@@ -1,9 +1,11 @@
 package usecase
 
 import (
-	"errors"
+	"context"
+	"log/slog"
 	"strings"
 
+	"platform/tracing"
 	"product_service/domain"
 	"product_service/repository"
 )
@@ -16,39 +18,48 @@ func NewCategoryUseCase(repo repository.CategoryRepository) CategoryUseCase {
 	return &categoryUseCase{repo: repo}
 }
 
-func (uc *categoryUseCase) Create(name string) (*domain.Category, error) {
+func (uc *categoryUseCase) Create(ctx context.Context, name string) (*domain.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryUseCase.Create")
+	defer span.End()
+
 	name = strings.TrimSpace(name)
 	if name == "" {
-		return nil, errors.New("category name is required")
+		return nil, domain.Invalid("category name is required")
 	}
 
-	exists, err := uc.repo.ExistsByName(name)
+	exists, err := uc.repo.ExistsByName(ctx, name)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, errors.New("category already exists")
+		return nil, domain.ErrCategoryExists
 	}
 
 	category := &domain.Category{
 		Name: name,
 	}
 
-	if err := uc.repo.Create(category); err != nil {
+	if err := uc.repo.Create(ctx, category); err != nil {
 		return nil, err
 	}
 
+	slog.InfoContext(ctx, "category created", slog.Int64("category_id", category.ID))
 	return category, nil
 }
 
-func (uc *categoryUseCase) GetAll() ([]*domain.Category, error) {
-	return uc.repo.GetAll()
+func (uc *categoryUseCase) GetAll(ctx context.Context) ([]*domain.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryUseCase.GetAll")
+	defer span.End()
+
+	return uc.repo.GetAll(ctx)
 }
 
-func (uc *categoryUseCase) GetByID(id int64) (*domain.Category, error) {
+func (uc *categoryUseCase) GetByID(ctx context.Context, id int64) (*domain.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryUseCase.GetByID")
+	defer span.End()
+
 	if id <= 0 {
-		return nil, errors.New("invalid category id")
+		return nil, domain.Invalid("invalid category id")
 	}
-	return uc.repo.GetByID(id)
+	return uc.repo.GetByID(ctx, id)
 }
-
@@ -1,17 +1,26 @@
 package usecase
 
-import "product_service/domain"
+import (
+	"context"
+	"product_service/domain"
+)
 
 type ProductUseCase interface {
 	Create(
+		ctx context.Context,
 		name string,
 		categoryID int64,
 		price float64,
 		initialStock int,
 	) (*domain.Product, error)
 
-	GetByID(id int64) (*domain.Product, error)
-	GetAll() ([]*domain.Product, error)
-	GetByCategory(categoryID int64) ([]*domain.Product, error)
-}
+	UpdatePrice(ctx context.Context, id int64, price float64) (*domain.Product, error)
+
+	GetByID(ctx context.Context, id int64) (*domain.Product, error)
+	GetAll(ctx context.Context) ([]*domain.Product, error)
 
+	// AnnounceAll enqueues product.created for every product, so consumers
+	// that started after the products were created can catch up.
+	AnnounceAll(ctx context.Context) (int, error)
+	GetByCategory(ctx context.Context, categoryID int64) ([]*domain.Product, error)
+}
@@ -1,53 +1,62 @@
 package usecase
 
 import (
-	"errors"
+	"platform/logging"
+
+	"context"
+	"events"
+	"log/slog"
+	"platform/tracing"
 	"product_service/domain"
 	"product_service/repository"
+	"time"
 )
 
 type productUseCase struct {
 	productRepo  repository.ProductRepository
 	categoryRepo repository.CategoryRepository
-	stockRepo    repository.StockRepository
+	transactor   repository.Transactor
 }
 
 func NewProductUseCase(
 	productRepo repository.ProductRepository,
 	categoryRepo repository.CategoryRepository,
-	stockRepo repository.StockRepository,
+	transactor repository.Transactor,
 ) ProductUseCase {
 	return &productUseCase{
 		productRepo:  productRepo,
 		categoryRepo: categoryRepo,
-		stockRepo:    stockRepo,
+		transactor:   transactor,
 	}
 }
 
 func (uc *productUseCase) Create(
+	ctx context.Context,
 	name string,
 	categoryID int64,
 	price float64,
 	initialStock int,
 ) (*domain.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductUseCase.Create")
+	defer span.End()
 
 	if name == "" {
-		return nil, errors.New("product name is required")
+		return nil, domain.Invalid("product name is required")
 	}
 	if price <= 0 {
-		return nil, errors.New("invalid price")
+		return nil, domain.Invalid("invalid price")
 	}
 	if initialStock < 0 {
-		return nil, errors.New("invalid stock")
+		return nil, domain.Invalid("invalid stock")
 	}
 
 	// ✅ category must exist
-	exists, err := uc.categoryRepo.ExistsByID(categoryID)
+	exists, err := uc.categoryRepo.ExistsByID(ctx, categoryID)
 	if err != nil {
 		return nil, err
 	}
 	if !exists {
-		return nil, errors.New("category does not exist")
+		return nil, domain.Invalid("category does not exist")
 	}
 
 	product := &domain.Product{
@@ -56,36 +65,152 @@ func (uc *productUseCase) Create(
 		Price:      price,
 	}
 
-	if err := uc.productRepo.Create(product); err != nil {
+	// Product, initial stock and product.created commit together so
+	// order_service's price list never misses a product.
+	err = uc.transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+		if err := tx.Products.Create(ctx, product); err != nil {
+			return err
+		}
+
+		stock := &domain.Stock{
+			ProductID: product.ID,
+			Quantity:  initialStock,
+		}
+		if err := tx.Stock.Create(ctx, stock); err != nil {
+			return err
+		}
+
+		return enqueue(ctx, tx, events.ProductCreated, events.ProductCreatedV1{
+			ProductID:  product.ID,
+			Name:       product.Name,
+			CategoryID: product.CategoryID,
+			Price:      product.Price,
+			OccurredAt: time.Now(),
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	stock := &domain.Stock{
-		ProductID: product.ID,
-		Quantity:  initialStock,
+	slog.InfoContext(ctx, "product created",
+		logging.ProductID(product.ID),
+		slog.Int64("category_id", product.CategoryID),
+		slog.Float64("price", product.Price),
+		slog.Int("initial_stock", initialStock),
+	)
+	return product, nil
+}
+
+// UpdatePrice changes the list price and publishes product.price_changed.
+// Setting the current price again changes nothing and publishes nothing.
+func (uc *productUseCase) UpdatePrice(ctx context.Context, id int64, price float64) (*domain.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductUseCase.UpdatePrice")
+	defer span.End()
+
+	if id <= 0 {
+		return nil, domain.Invalid("invalid product id")
+	}
+	if price <= 0 {
+		return nil, domain.Invalid("invalid price")
 	}
-	if err := uc.stockRepo.Create(stock); err != nil {
+
+	var product *domain.Product
+	var oldPrice float64
+	err := uc.transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+		var err error
+		product, err = tx.Products.LockByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if product.Price == price {
+			return nil
+		}
+
+		oldPrice = product.Price
+		if err := tx.Products.UpdatePrice(ctx, id, price); err != nil {
+			return err
+		}
+		product.Price = price
+
+		return enqueue(ctx, tx, events.ProductPriceChanged, events.ProductPriceChangedV1{
+			ProductID:  id,
+			OldPrice:   oldPrice,
+			Price:      price,
+			OccurredAt: time.Now(),
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	if oldPrice != 0 {
+		slog.InfoContext(ctx, "product price changed",
+			logging.ProductID(id),
+			slog.Float64("old_price", oldPrice),
+			slog.Float64("price", price),
+		)
+	}
 	return product, nil
 }
 
-func (uc *productUseCase) GetByID(id int64) (*domain.Product, error) {
+func (uc *productUseCase) GetByID(ctx context.Context, id int64) (*domain.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductUseCase.GetByID")
+	defer span.End()
+
 	if id <= 0 {
-		return nil, errors.New("invalid product id")
+		return nil, domain.Invalid("invalid product id")
+	}
+	return uc.productRepo.GetByID(ctx, id)
+}
+
+func (uc *productUseCase) AnnounceAll(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "ProductUseCase.AnnounceAll")
+	defer span.End()
+
+	var n int
+	err := uc.transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+		products, err := tx.Products.GetAll(ctx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, p := range products {
+			if err := enqueue(ctx, tx, events.ProductCreated, events.ProductCreatedV1{
+				ProductID:  p.ID,
+				Name:       p.Name,
+				CategoryID: p.CategoryID,
+				Price:      p.Price,
+				OccurredAt: now,
+			}); err != nil {
+				return err
+			}
+		}
+		n = len(products)
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
-	return uc.productRepo.GetByID(id)
+
+	slog.InfoContext(ctx, "products announced", slog.Int("count", n))
+	return n, nil
 }
 
-func (uc *productUseCase) GetAll() ([]*domain.Product, error) {
-	return uc.productRepo.GetAll()
+func (uc *productUseCase) GetAll(ctx context.Context) ([]*domain.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductUseCase.GetAll")
+	defer span.End()
+
+	return uc.productRepo.GetAll(ctx)
 }
 
-func (uc *productUseCase) GetByCategory(categoryID int64) ([]*domain.Product, error) {
+func (uc *productUseCase) GetByCategory(ctx context.Context, categoryID int64) ([]*domain.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductUseCase.GetByCategory")
+	defer span.End()
+
 	if categoryID <= 0 {
-		return nil, errors.New("invalid category id")
+		return nil, domain.Invalid("invalid category id")
 	}
-	return uc.productRepo.GetByCategory(categoryID)
+	return uc.productRepo.GetByCategory(ctx, categoryID)
 }
-
@@ -1,12 +1,13 @@
 package usecase
 
-import "product_service/domain"
+import (
+	"context"
+	"product_service/domain"
+)
 
 type StockUseCase interface {
-	Add(productID int64, qty int) error
-	GetByProductID(productID int64) (*domain.Stock, error)
-	ReserveForOrder(items []domain.OrderItem) error
-
+	Add(ctx context.Context, productID int64, qty int) error
+	GetByProductID(ctx context.Context, productID int64) (*domain.Stock, error)
+	ReserveForOrder(ctx context.Context, messageID string, orderID int64, items []domain.OrderItem) error
+	ReleaseForOrder(ctx context.Context, messageID string, orderID int64) error
 }
-
-
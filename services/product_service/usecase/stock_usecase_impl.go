@@ -1,59 +1,294 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 
+	"events"
+	"platform/logging"
+	"platform/tracing"
 	"product_service/domain"
+	"product_service/metrics"
 	"product_service/repository"
 )
 
+var (
+	errAlreadyReserved = errors.New("order already has a reservation")
+	errNoItems         = errors.New("order has no items")
+)
+
 type stockUseCase struct {
-	repo repository.StockRepository
+	repo       repository.StockRepository
+	transactor repository.Transactor
 }
 
-func NewStockUseCase(repo repository.StockRepository) StockUseCase {
-	return &stockUseCase{repo: repo}
+func NewStockUseCase(
+	repo repository.StockRepository,
+	transactor repository.Transactor,
+) StockUseCase {
+	return &stockUseCase{
+		repo:       repo,
+		transactor: transactor,
+	}
 }
 
-func (uc *stockUseCase) Add(productID int64, qty int) error {
+func (uc *stockUseCase) Add(ctx context.Context, productID int64, qty int) error {
+	ctx, span := tracing.Start(ctx, "StockUseCase.Add")
+	defer span.End()
+
 	if productID <= 0 {
-		return errors.New("invalid product id")
+		return domain.Invalid("invalid product id")
 	}
 	if qty <= 0 {
-		return errors.New("invalid quantity")
+		return domain.Invalid("invalid quantity")
 	}
 
-	stock, err := uc.repo.GetByProductID(productID)
+	quantity, err := uc.repo.Increase(ctx, productID, qty)
 	if err != nil {
 		return err
 	}
 
-	stock.Quantity += qty
-	return uc.repo.Update(stock)
+	slog.InfoContext(ctx, "stock added",
+		logging.ProductID(productID),
+		slog.Int("added", qty),
+		slog.Int("quantity", quantity),
+	)
+	return nil
 }
 
-func (uc *stockUseCase) GetByProductID(productID int64) (*domain.Stock, error) {
+func (uc *stockUseCase) GetByProductID(ctx context.Context, productID int64) (*domain.Stock, error) {
+	ctx, span := tracing.Start(ctx, "StockUseCase.GetByProductID")
+	defer span.End()
+
 	if productID <= 0 {
-		return nil, errors.New("invalid product id")
+		return nil, domain.Invalid("invalid product id")
 	}
-	return uc.repo.GetByProductID(productID)
+	return uc.repo.GetByProductID(ctx, productID)
 }
 
-func (uc *stockUseCase) ReserveForOrder(items []domain.OrderItem) error {
-	for _, item := range items {
-		stock, err := uc.repo.GetByProductID(item.ProductID)
+// ReserveForOrder reserves stock for every item of an order, or for none of
+// them. All stock rows of the order are locked up front, checked together and
+// only then decremented, inside one transaction that also writes the
+// inventory.reserved event. When the order cannot be served nothing is
+// reserved and an inventory.failed event listing the per-product shortfalls
+// is recorded instead; that is a handled outcome, not an error. Any other
+// failure is returned so the message is retried. messageID is recorded with
+// whichever outcome commits, so a redelivered order.created is acknowledged
+// without touching stock again.
+func (uc *stockUseCase) ReserveForOrder(ctx context.Context, messageID string, orderID int64, items []domain.OrderItem) error {
+	ctx, span := tracing.Start(ctx, "StockUseCase.ReserveForOrder")
+	defer span.End()
+	ctx = logging.With(ctx, logging.OrderID(orderID))
+
+	var reserved bool
+	err := uc.transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+		first, err := tx.FirstDelivery(ctx, "order.created", messageID)
+		if err != nil || !first {
+			return err
+		}
+
+		if len(items) == 0 {
+			return errNoItems
+		}
+
+		// The ledger is keyed by order: an order that already holds
+		// (or held) stock is never reserved a second time.
+		exists, err := tx.Reservations.ExistsForOrder(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errAlreadyReserved
+		}
+
+		demand := domain.Demand(items)
+		productIDs := domain.SortedProductIDs(demand)
+
+		stocks, err := tx.Stock.LockByProductIDs(ctx, productIDs)
 		if err != nil {
 			return err
 		}
 
-		if err := stock.Reserve(item.Quantity); err != nil {
+		if shortfalls := domain.CheckReservation(stocks, items); len(shortfalls) > 0 {
+			return &domain.ReservationError{Shortfalls: shortfalls}
+		}
+
+		for _, productID := range productIDs {
+			ok, err := tx.Stock.Reserve(ctx, productID, demand[productID])
+			if err != nil {
+				return err
+			}
+			if !ok {
+				// Cannot happen while the row lock is held, but never
+				// commit a partial reservation if it does.
+				return domain.ErrNotEnoughStock
+			}
+
+			err = tx.Reservations.Create(ctx, &domain.Reservation{
+				OrderID:   orderID,
+				ProductID: productID,
+				Quantity:  demand[productID],
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		reservedItems := make([]events.OrderItemV1, 0, len(items))
+		for _, item := range items {
+			reservedItems = append(reservedItems, events.OrderItemV1{
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+			})
+		}
+
+		reserved = true
+		return enqueue(ctx, tx, events.InventoryReserved, events.InventoryReservedV1{
+			OrderID: orderID,
+			Items:   reservedItems,
+		})
+	})
+	if err == nil {
+		if reserved {
+			metrics.Reservations.WithLabelValues(metrics.ReservationReserved).Inc()
+			slog.InfoContext(ctx, "stock reserved", slog.Int("items", len(items)))
+		}
+		return nil
+	}
+	if errors.Is(err, errAlreadyReserved) {
+		slog.InfoContext(ctx, "order already has a reservation, skipping")
+		return nil
+	}
+	if !isRejection(err) {
+		return err
+	}
+
+	failed := events.InventoryFailedV1{
+		OrderID: orderID,
+		Reason:  err.Error(),
+	}
+	var reservationErr *domain.ReservationError
+	if errors.As(err, &reservationErr) {
+		for _, s := range reservationErr.Shortfalls {
+			failed.Shortfalls = append(failed.Shortfalls, events.ShortfallV1{
+				ProductID: s.ProductID,
+				Requested: s.Requested,
+				Available: s.Available,
+				Reason:    s.Reason,
+			})
+		}
+	}
+
+	var recorded bool
+	failErr := uc.transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+		first, err := tx.FirstDelivery(ctx, "order.created", messageID)
+		if err != nil || !first {
+			return err
+		}
+		recorded = true
+		return enqueue(ctx, tx, events.InventoryFailed, failed)
+	})
+	if failErr != nil {
+		return fmt.Errorf("%v (and failed to record inventory.failed: %w)", err, failErr)
+	}
+	if !recorded {
+		return nil
+	}
+
+	metrics.Reservations.WithLabelValues(metrics.ReservationRejected).Inc()
+	for _, reason := range failureReasons(err, failed.Shortfalls) {
+		metrics.InventoryFailures.WithLabelValues(reason).Inc()
+	}
+
+	slog.WarnContext(ctx, "stock reservation rejected",
+		slog.String("reason", failed.Reason),
+		slog.Int("shortfalls", len(failed.Shortfalls)),
+	)
+	return nil
+}
+
+// isRejection reports whether err means the order itself cannot be
+// reserved, as opposed to a failure worth retrying.
+func isRejection(err error) bool {
+	var reservationErr *domain.ReservationError
+	return errors.As(err, &reservationErr) ||
+		errors.Is(err, domain.ErrNotEnoughStock) ||
+		errors.Is(err, errNoItems)
+}
+
+// failureReasons lists the distinct shortfall reasons of a rejected order,
+// or a single reason for the rejections that carry no shortfalls.
+func failureReasons(err error, shortfalls []events.ShortfallV1) []string {
+	if errors.Is(err, errNoItems) {
+		return []string{"no_items"}
+	}
+	if len(shortfalls) == 0 {
+		return []string{domain.ShortfallNotEnoughStock}
+	}
+
+	seen := map[string]bool{}
+	var reasons []string
+	for _, s := range shortfalls {
+		if !seen[s.Reason] {
+			seen[s.Reason] = true
+			reasons = append(reasons, s.Reason)
+		}
+	}
+	return reasons
+}
+
+// ReleaseForOrder gives back everything the order still holds according to
+// the reservations ledger. Releasing an order twice, or one that never
+// reserved anything, is a no-op.
+func (uc *stockUseCase) ReleaseForOrder(ctx context.Context, messageID string, orderID int64) error {
+	ctx, span := tracing.Start(ctx, "StockUseCase.ReleaseForOrder")
+	defer span.End()
+
+	if orderID <= 0 {
+		return domain.Invalid("invalid order id")
+	}
+	ctx = logging.With(ctx, logging.OrderID(orderID))
+
+	var released int
+	err := uc.transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+		first, err := tx.FirstDelivery(ctx, "order.cancelled", messageID)
+		if err != nil || !first {
 			return err
 		}
 
-		if err := uc.repo.Update(stock); err != nil {
+		reservations, err := tx.Reservations.LockActiveByOrder(ctx, orderID)
+		if err != nil {
 			return err
 		}
+		if len(reservations) == 0 {
+			return nil
+		}
+
+		for _, res := range reservations {
+			if err := tx.Stock.Release(ctx, res.ProductID, res.Quantity); err != nil {
+				return err
+			}
+		}
+
+		released = len(reservations)
+		return tx.Reservations.MarkReleased(ctx, orderID)
+	})
+	if err != nil {
+		return err
+	}
+
+	if released > 0 {
+		slog.InfoContext(ctx, "stock released", slog.Int("reservations", released))
 	}
 	return nil
 }
 
+func enqueue(ctx context.Context, tx *repository.Tx, eventType string, data interface{}) error {
+	event, err := events.New(domain.ServiceName, eventType, data)
+	if err != nil {
+		return err
+	}
+	return tx.Outbox.Add(ctx, event)
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"user_service/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims is the payload of every access token. Order and product services
+// decode the same shape, so keep field names stable.
+type Claims struct {
+	Email string      `json:"email"`
+	Role  domain.Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type TokenIssuer struct {
+	key       *rsa.PrivateKey
+	keyID     string
+	issuer    string
+	accessTTL time.Duration
+}
+
+func NewTokenIssuer(key *rsa.PrivateKey, issuer string, accessTTL time.Duration) (*TokenIssuer, error) {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+
+	return &TokenIssuer{
+		key:       key,
+		keyID:     base64.RawURLEncoding.EncodeToString(sum[:12]),
+		issuer:    issuer,
+		accessTTL: accessTTL,
+	}, nil
+}
+
+func (i *TokenIssuer) IssueAccessToken(user *domain.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(i.accessTTL)
+
+	claims := Claims{
+		Email: user.Email,
+		Role:  user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    i.issuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = i.keyID
+
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	return signed, expiresAt, nil
+}
+
+func (i *TokenIssuer) JWKS() JWKSet {
+	pub := i.key.PublicKey
+	return JWKSet{
+		Keys: []JWK{{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			Kid: i.keyID,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	}
+}
+
+// Verify checks an access token issued by i and returns its caller.
+func (i *TokenIssuer) Verify(tokenString string) (domain.Actor, error) {
+	var claims Claims
+
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(*jwt.Token) (interface{}, error) {
+		return &i.key.PublicKey, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(i.issuer),
+	)
+	if err != nil {
+		return domain.Actor{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil || userID <= 0 {
+		return domain.Actor{}, fmt.Errorf("%w: bad subject", ErrInvalidToken)
+	}
+	if !claims.Role.IsValid() {
+		return domain.Actor{}, fmt.Errorf("%w: bad role", ErrInvalidToken)
+	}
+
+	return domain.Actor{UserID: userID, Role: claims.Role}, nil
+}
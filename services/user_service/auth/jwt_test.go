@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"user_service/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestIssuer(t *testing.T) *TokenIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	issuer, err := NewTokenIssuer(key, "user_service", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("NewTokenIssuer: %v", err)
+	}
+	return issuer
+}
+
+// publicKey rebuilds the verification key the way order_service and
+// product_service do, from the published JWKS.
+func publicKey(t *testing.T, jwk JWK) *rsa.PublicKey {
+	t.Helper()
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		t.Fatalf("decode n: %v", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		t.Fatalf("decode e: %v", err)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+}
+
+func TestIssueAccessTokenVerifiesAgainstJWKS(t *testing.T) {
+	issuer := newTestIssuer(t)
+	user := &domain.User{ID: 42, Email: "ann@example.com", Role: domain.RoleWorker}
+
+	signed, expiresAt, err := issuer.IssueAccessToken(user)
+	if err != nil {
+		t.Fatalf("IssueAccessToken: %v", err)
+	}
+	if d := time.Until(expiresAt); d <= 14*time.Minute || d > 15*time.Minute {
+		t.Errorf("expires in %s, want 15m", d)
+	}
+
+	jwks := issuer.JWKS()
+	if len(jwks.Keys) != 1 {
+		t.Fatalf("JWKS has %d keys, want 1", len(jwks.Keys))
+	}
+	jwk := jwks.Keys[0]
+	if jwk.Kty != "RSA" || jwk.Alg != "RS256" || jwk.Use != "sig" || jwk.Kid == "" {
+		t.Errorf("unexpected JWK %+v", jwk)
+	}
+
+	var claims Claims
+	token, err := jwt.ParseWithClaims(signed, &claims, func(token *jwt.Token) (interface{}, error) {
+		if kid := token.Header["kid"]; kid != jwk.Kid {
+			t.Errorf("kid = %v, want %s", kid, jwk.Kid)
+		}
+		return publicKey(t, jwk), nil
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithIssuer("user_service"), jwt.WithExpirationRequired())
+	if err != nil || !token.Valid {
+		t.Fatalf("token does not verify: %v", err)
+	}
+	if claims.Subject != "42" || claims.Email != user.Email || claims.Role != domain.RoleWorker {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestKeyIDIsStablePerKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	a, _ := NewTokenIssuer(key, "user_service", time.Minute)
+	b, _ := NewTokenIssuer(key, "user_service", time.Hour)
+	other := newTestIssuer(t)
+
+	if a.keyID != b.keyID {
+		t.Errorf("same key got ids %s and %s", a.keyID, b.keyID)
+	}
+	if a.keyID == other.keyID {
+		t.Errorf("different keys share id %s", a.keyID)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	issuer := newTestIssuer(t)
+	valid, _, err := issuer.IssueAccessToken(&domain.User{ID: 42, Role: domain.RoleAdmin})
+	if err != nil {
+		t.Fatalf("IssueAccessToken: %v", err)
+	}
+
+	actor, err := issuer.Verify(valid)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if actor != (domain.Actor{UserID: 42, Role: domain.RoleAdmin}) {
+		t.Errorf("actor = %+v", actor)
+	}
+
+	sign := func(method jwt.SigningMethod, key interface{}, claims Claims) string {
+		t.Helper()
+		signed, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return signed
+	}
+	claims := func(edit func(*Claims)) Claims {
+		now := time.Now()
+		c := Claims{
+			Role: domain.RoleClient,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    "user_service",
+				Subject:   "42",
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+		}
+		edit(&c)
+		return c
+	}
+	otherKey := newTestIssuer(t).key
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"garbage", "not.a.token"},
+		{"signed by another key", sign(jwt.SigningMethodRS256, otherKey, claims(func(*Claims) {}))},
+		{"HMAC", sign(jwt.SigningMethodHS256, []byte("secret"), claims(func(*Claims) {}))},
+		{"expired", sign(jwt.SigningMethodRS256, issuer.key, claims(func(c *Claims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		}))},
+		{"no expiry", sign(jwt.SigningMethodRS256, issuer.key, claims(func(c *Claims) { c.ExpiresAt = nil }))},
+		{"other issuer", sign(jwt.SigningMethodRS256, issuer.key, claims(func(c *Claims) { c.Issuer = "someone" }))},
+		{"bad subject", sign(jwt.SigningMethodRS256, issuer.key, claims(func(c *Claims) { c.Subject = "ann" }))},
+		{"unknown role", sign(jwt.SigningMethodRS256, issuer.key, claims(func(c *Claims) { c.Role = "root" }))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := issuer.Verify(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("got %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+type AuthConfig struct {
+	Issuer     string        `yaml:"issuer"`
+	AccessTTL  time.Duration `yaml:"access_ttl"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl"`
+
+	// PrivateKeyFile, or else PrivateKey, holds the PEM encoded RSA key
+	// tokens are signed with.
+	PrivateKeyFile string `yaml:"private_key_file"`
+	PrivateKey     string `yaml:"private_key"`
+}
+
+func (c *AuthConfig) validate() error {
+	if c.Issuer == "" {
+		return errors.New("JWT_ISSUER must not be empty")
+	}
+	if c.AccessTTL <= 0 || c.RefreshTTL <= 0 {
+		return errors.New("JWT_ACCESS_TTL and JWT_REFRESH_TTL must be positive")
+	}
+	return nil
+}
+
+// SigningKey loads the RSA key tokens are signed with. When no key is
+// configured an ephemeral one is generated, which invalidates all tokens on
+// restart and is only meant for local development.
+func (c *AuthConfig) SigningKey() (*rsa.PrivateKey, error) {
+	pemData := []byte(c.PrivateKey)
+	if c.PrivateKeyFile != "" {
+		var err error
+		if pemData, err = os.ReadFile(c.PrivateKeyFile); err != nil {
+			return nil, fmt.Errorf("failed to read JWT_PRIVATE_KEY_FILE: %w", err)
+		}
+	}
+
+	if len(pemData) == 0 {
+		slog.Warn("JWT_PRIVATE_KEY_FILE is not set, generating an ephemeral signing key")
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		return key, nil
+	}
+
+	return parseRSAPrivateKey(pemData)
+}
+
+func parseRSAPrivateKey(pemData []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("invalid JWT private key: no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT private key: %w", err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid JWT private key: not an RSA key")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"platform/logging"
+	"platform/postgres"
+	"platform/rabbitmq"
+	"platform/settings"
+)
+
+// Config is everything the service reads at startup. See Load for where
+// each value comes from.
+type Config struct {
+	HTTP     HTTPConfig      `yaml:"http"`
+	Log      LogConfig       `yaml:"log"`
+	DB       postgres.Config `yaml:"db"`
+	RabbitMQ rabbitmq.Config `yaml:"rabbitmq"`
+	Auth     AuthConfig      `yaml:"auth"`
+
+	// PrintConfig is set by --print-config: print the config and exit.
+	PrintConfig bool `yaml:"-"`
+
+	// Args are the arguments left after the flags, e.g. a subcommand.
+	Args []string `yaml:"-"`
+}
+
+type HTTPConfig struct {
+	Addr string `yaml:"addr"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level"`
+}
+
+func defaults() *Config {
+	return &Config{
+		HTTP: HTTPConfig{Addr: ":8080"},
+		Log:  LogConfig{Level: "info"},
+		DB: postgres.Config{
+			Port:            "5432",
+			SSLMode:         "require",
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectTimeout:  time.Minute,
+			MigrateOnStart:  true,
+		},
+		RabbitMQ: rabbitmq.Config{Exchange: "events"},
+		Auth: AuthConfig{
+			Issuer:     "user_service",
+			AccessTTL:  15 * time.Minute,
+			RefreshTTL: 30 * 24 * time.Hour,
+		},
+	}
+}
+
+var fields = []settings.Setting[Config]{
+	settings.Bind("HTTP_ADDR", "http-addr", "address the HTTP server listens on", func(c *Config) any { return &c.HTTP.Addr }),
+	settings.Bind("LOG_LEVEL", "log-level", "debug, info, warn or error", func(c *Config) any { return &c.Log.Level }),
+
+	settings.Bind("DB_HOST", "db-host", "PostgreSQL host", func(c *Config) any { return &c.DB.Host }),
+	settings.Bind("DB_PORT", "db-port", "PostgreSQL port", func(c *Config) any { return &c.DB.Port }),
+	settings.Bind("DB_USER", "db-user", "PostgreSQL user", func(c *Config) any { return &c.DB.User }),
+	settings.Bind("DB_PASSWORD", "db-password", "PostgreSQL password", func(c *Config) any { return &c.DB.Password }),
+	settings.Bind("DB_NAME", "db-name", "PostgreSQL database", func(c *Config) any { return &c.DB.Name }),
+	settings.Bind("DB_SSLMODE", "db-sslmode", "PostgreSQL sslmode", func(c *Config) any { return &c.DB.SSLMode }),
+	settings.Bind("DB_MAX_OPEN_CONNS", "db-max-open-conns", "maximum open connections", func(c *Config) any { return &c.DB.MaxOpenConns }),
+	settings.Bind("DB_MAX_IDLE_CONNS", "db-max-idle-conns", "maximum idle connections", func(c *Config) any { return &c.DB.MaxIdleConns }),
+	settings.Bind("DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "maximum connection lifetime", func(c *Config) any { return &c.DB.ConnMaxLifetime }),
+	settings.Bind("DB_CONN_MAX_IDLE_TIME", "db-conn-max-idle-time", "maximum connection idle time", func(c *Config) any { return &c.DB.ConnMaxIdleTime }),
+	settings.Bind("DB_CONNECT_TIMEOUT", "db-connect-timeout", "how long to wait for the database at startup", func(c *Config) any { return &c.DB.ConnectTimeout }),
+	settings.Bind("DB_MIGRATE_ON_START", "db-migrate-on-start", "apply pending migrations at startup", func(c *Config) any { return &c.DB.MigrateOnStart }),
+
+	settings.Bind("RABBITMQ_URL", "rabbitmq-url", "RabbitMQ URL", func(c *Config) any { return &c.RabbitMQ.URL }),
+	settings.Bind("RABBITMQ_EXCHANGE", "rabbitmq-exchange", "events exchange", func(c *Config) any { return &c.RabbitMQ.Exchange }),
+
+	settings.Bind("JWT_ISSUER", "jwt-issuer", "issuer of the tokens", func(c *Config) any { return &c.Auth.Issuer }),
+	settings.Bind("JWT_ACCESS_TTL", "jwt-access-ttl", "access token lifetime", func(c *Config) any { return &c.Auth.AccessTTL }),
+	settings.Bind("JWT_REFRESH_TTL", "jwt-refresh-ttl", "refresh token lifetime", func(c *Config) any { return &c.Auth.RefreshTTL }),
+	settings.Bind("JWT_PRIVATE_KEY_FILE", "jwt-private-key-file", "PEM file with the RSA signing key", func(c *Config) any { return &c.Auth.PrivateKeyFile }),
+	settings.Bind("JWT_PRIVATE_KEY", "jwt-private-key", "PEM encoded RSA signing key", func(c *Config) any { return &c.Auth.PrivateKey }),
+}
+
+// Load builds the config from defaults, then the YAML file named by
+// --config or CONFIG_FILE, then the environment, then the flags in args,
+// and validates the result. args excludes the program name.
+func Load(args []string) (*Config, error) {
+	cfg := defaults()
+	opts, err := settings.Load(args, cfg, fields)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	cfg.PrintConfig = opts.PrintConfig
+	cfg.Args = opts.Args
+	return cfg, nil
+}
+
+func (c *Config) validate() error {
+	if _, _, err := net.SplitHostPort(c.HTTP.Addr); err != nil {
+		return fmt.Errorf("invalid HTTP_ADDR: %w", err)
+	}
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		return fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+	if err := c.DB.Validate(); err != nil {
+		return err
+	}
+	if err := c.RabbitMQ.Validate(); err != nil {
+		return err
+	}
+	return c.Auth.validate()
+}
+
+// Print writes c as YAML, in the shape of the config file, with
+// DB_PASSWORD, JWT_PRIVATE_KEY and the credentials in RABBITMQ_URL
+// redacted.
+func (c Config) Print(w io.Writer) error {
+	c.DB.Password = settings.Redact(c.DB.Password)
+	c.Auth.PrivateKey = settings.Redact(c.Auth.PrivateKey)
+	c.RabbitMQ.URL = settings.RedactURL(c.RabbitMQ.URL)
+	return settings.WriteYAML(w, c)
+}
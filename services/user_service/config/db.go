@@ -1,28 +0,0 @@
-package config
-
-import (
-    "database/sql"
-    "fmt"
-    "os"
-    
-    _ "github.com/lib/pq"
-)
-
-func ConnectDB() (*sql.DB, error) {
-    connStr := fmt.Sprintf(
-        "host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-        os.Getenv("DB_HOST"),
-        os.Getenv("DB_PORT"),
-        os.Getenv("DB_USER"),
-        os.Getenv("DB_PASSWORD"),
-        os.Getenv("DB_NAME"),
-        os.Getenv("DB_SSLMODE"),
-    )
-    
-    db, err := sql.Open("postgres", connStr)
-    if err != nil {
-        return nil, err
-    }
-    
-    return db, nil
-}
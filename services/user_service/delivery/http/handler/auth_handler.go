@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"platform/problem"
+	"user_service/auth"
+	"user_service/domain"
+	"user_service/usecase"
+)
+
+type AuthHandler struct {
+	authUC usecase.AuthUseCase
+	issuer *auth.TokenIssuer
+}
+
+func NewAuthHandler(authUC usecase.AuthUseCase, issuer *auth.TokenIssuer) *AuthHandler {
+	return &AuthHandler{authUC: authUC, issuer: issuer}
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type TokenResponse struct {
+	TokenType        string `json:"token_type"`
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt string `json:"refresh_expires_at"`
+}
+
+type LoginResponse struct {
+	User UserResponse `json:"user"`
+	TokenResponse
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	user, pair, err := h.authUC.Login(r.Context(), req.Email, req.Password)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	response := LoginResponse{
+		User: UserResponse{
+			ID:        user.ID,
+			FullName:  user.FullName,
+			Email:     user.Email,
+			Role:      user.Role,
+			Profile:   user.Profile,
+			CreatedAt: user.CreatedAt.Format(time.RFC3339),
+		},
+		TokenResponse: newTokenResponse(pair),
+	}
+
+	respondWithJSON(w, http.StatusOK, SuccessResponse{
+		Message: "Login successful",
+		Data:    response,
+	})
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	pair, err := h.authUC.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, SuccessResponse{
+		Message: "Token refreshed successfully",
+		Data:    newTokenResponse(pair),
+	})
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	if err := h.authUC.Logout(r.Context(), req.RefreshToken); err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, SuccessResponse{
+		Message: "Logout successful",
+	})
+}
+
+// JWKS publishes the public signing keys so other services can verify
+// access tokens without calling back into user_service.
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, h.issuer.JWKS())
+}
+
+func newTokenResponse(pair *domain.TokenPair) TokenResponse {
+	return TokenResponse{
+		TokenType:        "Bearer",
+		AccessToken:      pair.AccessToken,
+		ExpiresIn:        int64(time.Until(pair.AccessExpiresAt).Seconds()),
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"platform/health"
+)
+
+type HealthHandler struct {
+	liveness  health.Checks
+	readiness health.Checks
+}
+
+// NewHealthHandler serves liveness and readiness probes. Liveness checks
+// only what a restart would fix; readiness also covers the database and the
+// broker, which a restart cannot bring back.
+func NewHealthHandler(liveness, readiness health.Checks) *HealthHandler {
+	return &HealthHandler{liveness: liveness, readiness: readiness}
+}
+
+// Live answers 200 while the process is able to do its work at all.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, h.liveness.Run(r.Context()))
+}
+
+// Ready answers 200 while the service can serve requests and events.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, h.readiness.Run(r.Context()))
+}
+
+func writeReport(w http.ResponseWriter, report *health.Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !report.Up() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
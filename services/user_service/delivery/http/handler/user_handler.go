@@ -1,12 +1,16 @@
 package handler
 
 import (
-	"user_service/domain"
-	"user_service/usecase"
+	"platform/problem"
+
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
+	"user_service/delivery/http/middleware"
+	"user_service/domain"
+	"user_service/usecase"
 )
 
 type UserHandler struct {
@@ -18,11 +22,14 @@ func NewUserHandler(userUC usecase.UserUseCase) *UserHandler {
 }
 
 type RegisterRequest struct {
-	Email    string          `json:"email"`
-	Password string          `json:"password"`
-	FullName string          `json:"full_name"`
-	Role     domain.Role     `json:"role"`
-	Profile  domain.Profile  `json:"profile"`
+	Email    string         `json:"email"`
+	Password string         `json:"password"`
+	FullName string         `json:"full_name"`
+	Profile  domain.Profile `json:"profile"`
+}
+
+type ChangeRoleRequest struct {
+	Role domain.Role `json:"role"`
 }
 
 type LoginRequest struct {
@@ -31,16 +38,12 @@ type LoginRequest struct {
 }
 
 type UserResponse struct {
-	ID        int64           `json:"id"`
-	FullName  string          `json:"full_name"`
-	Email     string          `json:"email"`
-	Role      domain.Role     `json:"role"`
-	Profile   domain.Profile  `json:"profile"`
-	CreatedAt string          `json:"created_at"`
-}
-
-type ErrorResponse struct {
-	Error string `json:"error"`
+	ID        int64          `json:"id"`
+	FullName  string         `json:"full_name"`
+	Email     string         `json:"email"`
+	Role      domain.Role    `json:"role"`
+	Profile   domain.Profile `json:"profile"`
+	CreatedAt string         `json:"created_at"`
 }
 
 type SuccessResponse struct {
@@ -50,25 +53,25 @@ type SuccessResponse struct {
 
 func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		problem.Write(w, r, http.StatusMethodNotAllowed, "")
 		return
 	}
 
 	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		problem.Write(w, r, http.StatusBadRequest, "invalid request payload")
 		return
 	}
 
 	user, err := h.userUC.Register(
+		r.Context(),
 		req.Email,
 		req.Password,
 		req.FullName,
-		req.Role,
 		req.Profile,
 	)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error())
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -87,21 +90,29 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		problem.Write(w, r, http.StatusMethodNotAllowed, "")
 		return
 	}
 
-	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	// Extract user ID from URL path or query parameter
+	// Example: /users/{id}
+	userID := r.URL.Query().Get("id")
+	if userID == "" {
+		problem.Write(w, r, http.StatusBadRequest, "user id is required")
 		return
 	}
 
-	user, err := h.userUC.Login(req.Email, req.Password)
+	var id int64
+	if _, err := fmt.Sscanf(userID, "%d", &id); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	user, err := h.userUC.GetUserByID(r.Context(), id)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err.Error())
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -115,64 +126,62 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respondWithJSON(w, http.StatusOK, SuccessResponse{
-		Message: "Login successful",
+		Message: "User retrieved successfully",
 		Data:    response,
 	})
 }
 
-func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// ChangeRole sets the role of the user in the path. Admins only.
+func (h *UserHandler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.ActorFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, domain.ErrUnauthorized)
 		return
 	}
 
-	// Extract user ID from URL path or query parameter
-	// Example: /users/{id}
-	userID := r.URL.Query().Get("id")
-	if userID == "" {
-		respondWithError(w, http.StatusBadRequest, "User ID is required")
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid user id")
 		return
 	}
 
-	var id int64
-	if _, err := fmt.Sscanf(userID, "%d", &id); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+	var req ChangeRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid request payload")
 		return
 	}
 
-	user, err := h.userUC.GetUserByID(id)
+	user, err := h.userUC.ChangeRole(r.Context(), actor, id, req.Role)
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, err.Error())
+		problem.Error(w, r, err)
 		return
 	}
 
-	response := UserResponse{
-		ID:        user.ID,
-		FullName:  user.FullName,
-		Email:     user.Email,
-		Role:      user.Role,
-		Profile:   user.Profile,
-		CreatedAt: user.CreatedAt.Format(time.RFC3339),
-	}
-
 	respondWithJSON(w, http.StatusOK, SuccessResponse{
-		Message: "User retrieved successfully",
-		Data:    response,
+		Message: "Role changed successfully",
+		Data: UserResponse{
+			ID:        user.ID,
+			FullName:  user.FullName,
+			Email:     user.Email,
+			Role:      user.Role,
+			Profile:   user.Profile,
+			CreatedAt: user.CreatedAt.Format(time.RFC3339),
+		},
 	})
 }
 
 func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		problem.Write(w, r, http.StatusMethodNotAllowed, "")
 		return
 	}
 
 	// Optional: Add authentication middleware here
 	// Example: Only admins can get all users
 
-	users, err := h.userUC.GetAllUsers()
+	users, err := h.userUC.GetAllUsers(r.Context())
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -199,7 +208,3 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.WriteHeader(code)
 	json.NewEncoder(w).Encode(payload)
 }
-
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, ErrorResponse{Error: message})
-}
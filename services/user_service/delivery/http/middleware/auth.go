@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"platform/logging"
+	"platform/problem"
+	"user_service/domain"
+)
+
+type TokenVerifier interface {
+	Verify(token string) (domain.Actor, error)
+}
+
+type contextKey int
+
+const actorKey contextKey = iota
+
+// Authenticate rejects requests without a valid bearer token and stores the
+// caller in the request context.
+func Authenticate(verifier TokenVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			scheme, token, ok := strings.Cut(header, " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+				unauthorized(w, r, "missing bearer token")
+				return
+			}
+
+			actor, err := verifier.Verify(strings.TrimSpace(token))
+			if err != nil {
+				unauthorized(w, r, "invalid token")
+				return
+			}
+
+			ctx := logging.With(WithActor(r.Context(), actor), logging.UserID(actor.UserID))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func WithActor(ctx context.Context, actor domain.Actor) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+func ActorFromContext(ctx context.Context) (domain.Actor, bool) {
+	actor, ok := ctx.Value(actorKey).(domain.Actor)
+	return actor, ok
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, msg string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="user_service"`)
+	problem.Write(w, r, http.StatusUnauthorized, msg)
+}
@@ -2,25 +2,35 @@ package routes
 
 import (
 	"user_service/delivery/http/handler"
-	"encoding/json"
+	"user_service/metrics"
 	"net/http"
 )
 
-func SetupUserRoutes(userHandler *handler.UserHandler) *http.ServeMux {
+func SetupUserRoutes(
+	userHandler *handler.UserHandler,
+	authHandler *handler.AuthHandler,
+	outboxHandler *handler.OutboxHandler,
+	healthHandler *handler.HealthHandler,
+	authenticate func(http.Handler) http.Handler,
+) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// Use Go 1.22+ pattern matching
 	mux.HandleFunc("POST /register", userHandler.Register)
-	mux.HandleFunc("POST /login", userHandler.Login)
+	mux.HandleFunc("POST /login", authHandler.Login)
+	mux.HandleFunc("POST /token/refresh", authHandler.Refresh)
+	mux.HandleFunc("POST /logout", authHandler.Logout)
+	mux.HandleFunc("GET /.well-known/jwks.json", authHandler.JWKS)
 	mux.HandleFunc("GET /users/{id}", userHandler.GetUser)
 	mux.HandleFunc("GET /users", userHandler.GetAllUsers)
+	mux.Handle("PUT /users/{id}/role", authenticate(http.HandlerFunc(userHandler.ChangeRole)))
 
-	// Health check
-	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
-	})
+	mux.HandleFunc("GET /outbox/stats", outboxHandler.Stats)
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	// Health checks
+	mux.HandleFunc("GET /healthz", healthHandler.Live)
+	mux.HandleFunc("GET /readyz", healthHandler.Ready)
 
 	return mux
 }
@@ -0,0 +1,28 @@
+package domain
+
+import "platform/domainerr"
+
+// Kinds of failure a caller can act on. They are shared by every service;
+// see domainerr.
+var (
+	ErrInvalid      = domainerr.ErrInvalid
+	ErrUnauthorized = domainerr.ErrUnauthorized
+	ErrForbidden    = domainerr.ErrForbidden
+	ErrNotFound     = domainerr.ErrNotFound
+	ErrConflict     = domainerr.ErrConflict
+)
+
+var (
+	ErrUserNotFound        = &Error{Kind: ErrNotFound, Msg: "user not found"}
+	ErrEmailTaken          = &Error{Kind: ErrConflict, Msg: "email already registered"}
+	ErrInvalidCredentials  = &Error{Kind: ErrUnauthorized, Msg: "invalid credentials"}
+	ErrInvalidRefreshToken = &Error{Kind: ErrUnauthorized, Msg: "invalid refresh token"}
+)
+
+// Error is a domain error whose message is safe to show to the caller.
+type Error = domainerr.Error
+
+// Invalid reports input the caller has to fix.
+func Invalid(msg string) error {
+	return domainerr.Invalid(msg)
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// OutboxMessage is an event stored in the same transaction as the change
+// that produced it and published to RabbitMQ later by the outbox relay.
+type OutboxMessage struct {
+	ID         int64
+	MessageID  string
+	RoutingKey string
+	Payload    []byte
+	// Headers carries the correlation id and trace context the message was
+	// recorded with.
+	Headers       map[string]string
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	SentAt        *time.Time
+}
+
+type OutboxStats struct {
+	Pending         int64      `json:"pending"`
+	OldestPendingAt *time.Time `json:"oldest_pending_at,omitempty"`
+	LagSeconds      float64    `json:"lag_seconds"`
+	MaxAttempts     int        `json:"max_attempts"`
+	LastSentAt      *time.Time `json:"last_sent_at,omitempty"`
+}
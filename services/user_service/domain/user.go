@@ -21,6 +21,16 @@ func (r Role) IsValid() bool {
 	}
 }
 
+// Actor is the authenticated caller of a use case.
+type Actor struct {
+	UserID int64
+	Role   Role
+}
+
+func (a Actor) IsAdmin() bool {
+	return a.Role == RoleAdmin
+}
+
 type Profile struct {
 	FirstName string    `json:"first_name,omitempty"`
 	LastName  string    `json:"last_name,omitempty"`
@@ -1,79 +1,219 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
 	"net/http"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"platform/health"
+	"platform/httpmw"
+	"platform/logging"
+	"platform/postgres"
+	"platform/rabbitmq"
+	"platform/tracing"
+	"user_service/auth"
 	"user_service/config"
 	"user_service/delivery/http/handler"
+	"user_service/delivery/http/middleware"
 	"user_service/delivery/http/routes"
+	"user_service/domain"
 	"user_service/messaging"
+	"user_service/metrics"
+	"user_service/migrations"
 	"user_service/repository"
 	"user_service/usecase"
-
-	"github.com/streadway/amqp"
 )
 
+// shutdownTimeout bounds draining requests and the outbox relay
+// on SIGTERM. It stays below the grace period Docker Compose allows before
+// it kills the container.
+const shutdownTimeout = 20 * time.Second
+
+// requestTimeout is the deadline of every HTTP request.
+const requestTimeout = 10 * time.Second
+
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// -------------------------
+	// Config
+	// -------------------------
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		logging.Fatal("invalid config", logging.Err(err))
+	}
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			logging.Fatal("failed to print config", logging.Err(err))
+		}
+		return
+	}
+	if len(cfg.Args) > 0 && cfg.Args[0] != "migrate" && cfg.Args[0] != "set-role" {
+		logging.Fatal("unknown command", "command", cfg.Args[0])
+	}
+
+	// -------------------------
+	// Logging
+	// -------------------------
+	if err := logging.Setup(cfg.Log.Level, domain.ServiceName); err != nil {
+		logging.Fatal("invalid LOG_LEVEL", logging.Err(err))
+	}
+
+	// -------------------------
+	// Tracing
+	// -------------------------
+	shutdownTracing, err := tracing.Setup(context.Background(), domain.ServiceName)
+	if err != nil {
+		logging.Fatal("failed to set up tracing", logging.Err(err))
+	}
+	defer shutdownTracing(context.Background())
+
 	// -------------------------
 	// Database
 	// -------------------------
-	db, err := config.ConnectDB()
+	db, err := postgres.Connect(ctx, &cfg.DB)
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatal("failed to connect to database", logging.Err(err))
 	}
 	defer db.Close()
 
 	// -------------------------
-	// RabbitMQ
+	// Migrations
 	// -------------------------
-	rabbitURL := os.Getenv("RABBITMQ_URL")
-	if rabbitURL == "" {
-		log.Fatal("RABBITMQ_URL is not set")
+	if len(cfg.Args) > 0 && cfg.Args[0] == "migrate" {
+		if err := runMigrate(ctx, db, cfg.Args[1:]); err != nil {
+			logging.Fatal("migrate failed", logging.Err(err))
+		}
+		return
 	}
-	if !strings.HasPrefix(rabbitURL, "amqp://") && !strings.HasPrefix(rabbitURL, "amqps://") {
-		log.Fatal("invalid RABBITMQ_URL: " + rabbitURL)
+	if cfg.DB.MigrateOnStart {
+		if _, err := migrations.Migrator.Up(ctx, db); err != nil {
+			logging.Fatal("failed to migrate database", logging.Err(err))
+		}
+	}
+	if len(cfg.Args) > 0 {
+		if err := runSetRole(ctx, db, cfg.Args[1:]); err != nil {
+			logging.Fatal("set-role failed", logging.Err(err))
+		}
+		return
 	}
 
-	conn, err := amqp.Dial(rabbitURL)
+	// -------------------------
+	// RabbitMQ
+	// -------------------------
+	// The connection redials on its own if the broker goes away.
+	conn, err := rabbitmq.Dial(cfg.RabbitMQ.URL, cfg.RabbitMQ.Exchange)
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatal("failed to connect to rabbitmq", logging.Err(err))
 	}
 	defer conn.Close()
 
-	ch, err := conn.Channel()
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer ch.Close()
-
-	err = ch.ExchangeDeclare(
-		"events",
-		"topic",
-		true,
-		false,
-		false,
-		false,
-		nil,
+	publisher := messaging.NewRabbitPublisher(conn)
+
+	// -------------------------
+	// Application
+	// -------------------------
+	transactor := repository.NewTransactor(db)
+	userRepo := repository.NewPostgresRepository(db)
+	userUC := usecase.NewUserUseCase(userRepo, transactor)
+
+	// -------------------------
+	// Outbox relay
+	// -------------------------
+	relay := messaging.NewOutboxRelay(transactor, repository.NewOutboxPostgres(db), publisher, cfg.RabbitMQ.Exchange)
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(relayCtx)
+	}()
+	outboxHandler := handler.NewOutboxHandler(relay)
+	userHandler := handler.NewUserHandler(userUC)
+
+	// -------------------------
+	// Metrics
+	// -------------------------
+	metrics.RegisterDB(db)
+	metrics.RegisterOutbox(relay.Stats)
+
+	// -------------------------
+	// Health
+	// -------------------------
+	// Nothing a restart would fix can break here, so liveness has no checks.
+	healthHandler := handler.NewHealthHandler(
+		health.Checks{},
+		health.Checks{
+			"postgres": db.PingContext,
+			"rabbitmq": conn.Check,
+		},
 	)
+
+	// -------------------------
+	// Authentication
+	// -------------------------
+	signingKey, err := cfg.Auth.SigningKey()
+	if err != nil {
+		logging.Fatal("invalid auth config", logging.Err(err))
+	}
+
+	tokenIssuer, err := auth.NewTokenIssuer(signingKey, cfg.Auth.Issuer, cfg.Auth.AccessTTL)
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatal("failed to create token issuer", logging.Err(err))
 	}
 
-	publisher := messaging.NewRabbitPublisher(ch)
+	tokenRepo := repository.NewRefreshTokenPostgres(db)
+	authUC := usecase.NewAuthUseCase(userUC, tokenRepo, tokenIssuer, cfg.Auth.RefreshTTL)
+	authHandler := handler.NewAuthHandler(authUC, tokenIssuer)
+
+	router := routes.SetupUserRoutes(userHandler, authHandler, outboxHandler, healthHandler, middleware.Authenticate(tokenIssuer))
+
+	httpHandler := middleware.Timeout(requestTimeout)(
+		httpmw.Trace(httpmw.AccessLog(middleware.Metrics(router))),
+	)
+	server := &http.Server{Addr: cfg.HTTP.Addr, Handler: httpHandler}
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("User Service running", "addr", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err := <-serverErr:
+		logging.Fatal("http server stopped", logging.Err(err))
+	}
 
 	// -------------------------
-	// Application
+	// Shutdown
 	// -------------------------
-	userRepo := repository.NewPostgresRepository(db)
-	userUC := usecase.NewUserUseCase(userRepo, publisher)
-	userHandler := handler.NewUserHandler(userUC)
+	// Requests first, then the relay, so the events they
+	// enqueue still go out. Deferred calls then close the RabbitMQ
+	// connection and the database and flush traces.
+	stop() // a second signal kills the process right away
+	slog.Info("shutting down", "timeout", shutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-	router := routes.SetupUserRoutes(userHandler)
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("http requests not drained", logging.Err(err))
+	}
+	stopRelay()
+	select {
+	case <-relayDone:
+	case <-shutdownCtx.Done():
+		slog.Error("outbox relay not stopped", logging.Err(shutdownCtx.Err()))
+	}
 
-	log.Println("User Service running on :8080")
-	log.Fatal(http.ListenAndServe(":8080", router))
+	slog.Info("User Service stopped")
 }
 
@@ -0,0 +1,241 @@
+package messaging
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"platform/logging"
+	"platform/tracing"
+	"user_service/domain"
+	"user_service/metrics"
+	"user_service/repository"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// batchTimeout bounds one batch, including waiting for publisher confirms,
+// so a broker that stops answering cannot hold the outbox rows locked.
+// Publishing stops after publishTimeout; the rest of the time is left for
+// marking what was published and committing, so a slow broker shortens a
+// batch instead of rolling it back and republishing all of it.
+const (
+	batchTimeout   = 30 * time.Second
+	publishTimeout = 20 * time.Second
+)
+
+// Sent rows are kept for a while to help investigate duplicates, then
+// deleted by the relay in batches of purgeBatchSize, at most once per
+// purgeInterval.
+const (
+	retention      = 7 * 24 * time.Hour
+	purgeInterval  = time.Hour
+	purgeBatchSize = 1000
+)
+
+// EventPublisher must only return nil once the broker has taken
+// responsibility for the message; the relay marks it sent right after.
+// Implementations forward the trace context and correlation id of ctx in
+// the message headers.
+type EventPublisher interface {
+	Publish(ctx context.Context, routingKey, messageID string, payload []byte) error
+}
+
+// OutboxRelay moves events from the outbox table to RabbitMQ. Delivery is
+// at-least-once: a crash between publishing and committing the "sent" mark
+// republishes the batch, so consumers must tolerate duplicates.
+type OutboxRelay struct {
+	transactor repository.Transactor
+	outbox     repository.OutboxRepository
+	publisher  EventPublisher
+	exchange   string
+
+	interval    time.Duration
+	batchSize   int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+
+	// lastSent is when this relay last committed a published message.
+	lastSent atomic.Pointer[time.Time]
+}
+
+func NewOutboxRelay(
+	transactor repository.Transactor,
+	outbox repository.OutboxRepository,
+	publisher EventPublisher,
+	exchange string,
+) *OutboxRelay {
+	return &OutboxRelay{
+		transactor:  transactor,
+		outbox:      outbox,
+		publisher:   publisher,
+		exchange:    exchange,
+		interval:    time.Second,
+		batchSize:   100,
+		baseBackoff: time.Second,
+		maxBackoff:  5 * time.Minute,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled. A full batch is followed
+// immediately by the next one so a backlog drains without waiting. A batch
+// that has started is finished even if ctx is cancelled meanwhile, so
+// what it published is also marked sent. Between batches it purges old
+// sent rows.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	var lastPurge time.Time
+	for {
+		if time.Since(lastPurge) >= purgeInterval {
+			lastPurge = time.Now()
+			r.purge(ctx)
+		}
+
+		batchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), batchTimeout)
+		n, err := r.relayBatch(batchCtx)
+		cancel()
+		if err != nil {
+			slog.ErrorContext(ctx, "outbox relay failed", logging.Err(err))
+		}
+
+		if err == nil && n == r.batchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stats describes the unsent messages. LastSentAt is when this replica
+// last published one, so it is empty until it has.
+func (r *OutboxRelay) Stats(ctx context.Context) (*domain.OutboxStats, error) {
+	stats, err := r.outbox.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.LastSentAt = r.lastSent.Load()
+	return stats, nil
+}
+
+// purge deletes rows sent more than retention ago, one batch at a time, so
+// no single statement locks or rewrites a large part of the table.
+func (r *OutboxRelay) purge(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, batchTimeout)
+	defer cancel()
+
+	var deleted int64
+	for ctx.Err() == nil {
+		n, err := r.outbox.DeleteSent(ctx, retention, purgeBatchSize)
+		if err != nil {
+			slog.ErrorContext(ctx, "outbox purge failed", logging.Err(err))
+			break
+		}
+		deleted += n
+		if n < purgeBatchSize {
+			break
+		}
+	}
+
+	if deleted > 0 {
+		slog.InfoContext(ctx, "purged sent outbox messages", "deleted", deleted)
+	}
+}
+
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	var fetched, sent int
+
+	err := r.transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+		messages, err := tx.Outbox.FetchDue(ctx, r.batchSize)
+		if err != nil {
+			return err
+		}
+		fetched = len(messages)
+
+		publishCtx, cancel := context.WithTimeout(ctx, publishTimeout)
+		defer cancel()
+
+		for _, m := range messages {
+			// Rows not reached are left as they are and come up again on
+			// the next batch.
+			if publishCtx.Err() != nil {
+				break
+			}
+
+			if err := r.publish(publishCtx, m); err != nil {
+				if publishCtx.Err() != nil {
+					// Cut off by the deadline rather than refused by the
+					// broker, so it does not count as a failed attempt.
+					break
+				}
+				if err := tx.Outbox.MarkFailed(ctx, m.ID, err.Error(), r.backoff(m.Attempts)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Outbox.MarkSent(ctx, m.ID); err != nil {
+				return err
+			}
+			sent++
+		}
+
+		return nil
+	})
+
+	if err == nil && sent > 0 {
+		now := time.Now()
+		r.lastSent.Store(&now)
+	}
+	return fetched, err
+}
+
+// publish sends m as part of the trace that enqueued it, so the publish span
+// sits under the request that caused the event rather than the relay tick.
+func (r *OutboxRelay) publish(ctx context.Context, m *domain.OutboxMessage) error {
+	ctx, span := tracing.Start(
+		tracing.Extract(ctx, m.Headers),
+		"publish "+m.RoutingKey,
+		attribute.String("messaging.system", "rabbitmq"),
+		attribute.String("messaging.destination.name", r.exchange),
+		attribute.String("messaging.message.id", m.MessageID),
+	)
+
+	ctx = logging.With(ctx, logging.EventType(m.RoutingKey), logging.MessageID(m.MessageID))
+
+	err := r.publisher.Publish(ctx, m.RoutingKey, m.MessageID, m.Payload)
+	tracing.End(span, err)
+	if err != nil {
+		metrics.EventsPublished.WithLabelValues(m.RoutingKey, metrics.OutcomeFailed).Inc()
+		slog.WarnContext(ctx, "failed to publish outbox message",
+			slog.Int64("outbox_id", m.ID),
+			slog.Int("attempt", m.Attempts+1),
+			logging.Err(err),
+		)
+		return err
+	}
+
+	metrics.EventsPublished.WithLabelValues(m.RoutingKey, metrics.OutcomePublished).Inc()
+	slog.DebugContext(ctx, "published outbox message", slog.Int64("outbox_id", m.ID))
+	return nil
+}
+
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	d := r.baseBackoff
+	for i := 0; i < attempts && d < r.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > r.maxBackoff {
+		d = r.maxBackoff
+	}
+	return d
+}
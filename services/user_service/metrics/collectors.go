@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"user_service/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeTimeout bounds the queries a scrape runs against the database.
+const scrapeTimeout = 5 * time.Second
+
+// RegisterOutbox exposes the outbox backlog as reported by stats, which is
+// called on every scrape.
+func RegisterOutbox(stats func(ctx context.Context) (*domain.OutboxStats, error)) {
+	Registry.MustRegister(&outboxCollector{stats: stats})
+}
+
+var (
+	outboxPendingDesc = prometheus.NewDesc(
+		"outbox_pending_messages",
+		"Outbox messages not yet published.",
+		nil, nil,
+	)
+	outboxLagDesc = prometheus.NewDesc(
+		"outbox_lag_seconds",
+		"Age of the oldest unpublished outbox message.",
+		nil, nil,
+	)
+)
+
+type outboxCollector struct {
+	stats func(ctx context.Context) (*domain.OutboxStats, error)
+}
+
+func (c *outboxCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- outboxPendingDesc
+	ch <- outboxLagDesc
+}
+
+func (c *outboxCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	stats, err := c.stats(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(outboxPendingDesc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(outboxPendingDesc, prometheus.GaugeValue, float64(stats.Pending))
+	ch <- prometheus.MustNewConstMetric(outboxLagDesc, prometheus.GaugeValue, stats.LagSeconds)
+}
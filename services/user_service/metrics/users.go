@@ -0,0 +1,30 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Outcomes of a login attempt.
+const (
+	LoginSucceeded = "succeeded"
+	LoginRejected  = "rejected"
+)
+
+var (
+	UsersRegistered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "users_registered_total",
+		Help: "Users registered by role.",
+	}, []string{"role"})
+
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "logins_total",
+		Help: "Login attempts by outcome. Rejected attempts used an unknown email or a wrong password.",
+	}, []string{"outcome"})
+
+	RefreshTokenReuse = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "refresh_token_reuse_total",
+		Help: "Refresh tokens presented again after rotation, each of which revoked all sessions of its user.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(UsersRegistered, Logins, RefreshTokenReuse)
+}
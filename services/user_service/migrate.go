@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"user_service/migrations"
+)
+
+var errMigrateUsage = errors.New("usage: migrate [up | down [N] | status]")
+
+// runMigrate implements the migrate subcommand. up applies every pending
+// migration, down reverts the last N (default 1) and status lists them all.
+func runMigrate(ctx context.Context, db *sql.DB, args []string) error {
+	cmd := "up"
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "up":
+		if len(args) > 0 {
+			return errMigrateUsage
+		}
+		n, err := migrations.Migrator.Up(ctx, db)
+		fmt.Printf("applied %d migration(s)\n", n)
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			return errMigrateUsage
+		}
+		if len(args) == 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[0])
+			}
+		}
+		n, err := migrations.Migrator.Down(ctx, db, steps)
+		fmt.Printf("reverted %d migration(s)\n", n)
+		return err
+
+	case "status":
+		if len(args) > 0 {
+			return errMigrateUsage
+		}
+		states, err := migrations.Migrator.Status(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Unknown {
+				applied += " (unknown to this binary)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+
+	default:
+		return errMigrateUsage
+	}
+}
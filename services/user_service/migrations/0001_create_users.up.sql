@@ -19,4 +19,3 @@ CREATE TABLE IF NOT EXISTS roles (
     role TEXT NOT NULL,
     UNIQUE (user_id, role)
 );
-
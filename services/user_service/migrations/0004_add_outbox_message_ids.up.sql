@@ -0,0 +1,2 @@
+ALTER TABLE outbox
+    ADD COLUMN IF NOT EXISTS message_id UUID NOT NULL UNIQUE DEFAULT gen_random_uuid();
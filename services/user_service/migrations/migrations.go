@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"embed"
+
+	"platform/migrate"
+)
+
+// files holds every migration as <version>_<name>.up.sql and
+// <version>_<name>.down.sql.
+//
+//go:embed *.sql
+var files embed.FS
+
+// lockID is the advisory lock held while migrating. Each service has its
+// own, in case two of them are ever pointed at the same database.
+const lockID = 7_214_052_023
+
+// Migrator applies this service's migrations.
+var Migrator = migrate.New(files, lockID)
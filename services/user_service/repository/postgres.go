@@ -1,21 +1,33 @@
 package repository
 
 import (
+	"context"
 	"user_service/domain"
+	"platform/tracing"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// uniqueViolation is the PostgreSQL error code for a unique constraint
+// violation.
+const uniqueViolation = "23505"
+
 type postgresRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewPostgresRepository(db *sql.DB) UserRepository {
+func NewPostgresRepository(db DBTX) UserRepository {
 	return &postgresRepository{db: db}
 }
 
-func (r *postgresRepository) GetByEmail(email string) (*domain.User, error) {
+func (r *postgresRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	_, span := tracing.Start(ctx, "UserRepository.GetByEmail")
+	defer span.End()
+
 	query := `
 		SELECT 
 			u.id, u.full_name, u.email, u.password, u.created_at,
@@ -28,10 +40,10 @@ func (r *postgresRepository) GetByEmail(email string) (*domain.User, error) {
 		LIMIT 1
 	`
 
-	user, err := r.scanUser(query, email)
+	user, err := r.scanUser(ctx, query, email)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user with email %s not found", email)
+			return nil, domain.ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -39,7 +51,10 @@ func (r *postgresRepository) GetByEmail(email string) (*domain.User, error) {
 	return user, nil
 }
 
-func (r *postgresRepository) GetByID(id int64) (*domain.User, error) {
+func (r *postgresRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	_, span := tracing.Start(ctx, "UserRepository.GetByID")
+	defer span.End()
+
 	query := `
 		SELECT 
 			u.id, u.full_name, u.email, u.password, u.created_at,
@@ -52,10 +67,10 @@ func (r *postgresRepository) GetByID(id int64) (*domain.User, error) {
 		LIMIT 1
 	`
 
-	user, err := r.scanUser(query, id)
+	user, err := r.scanUser(ctx, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user with ID %d not found", id)
+			return nil, domain.ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -63,13 +78,16 @@ func (r *postgresRepository) GetByID(id int64) (*domain.User, error) {
 	return user, nil
 }
 
-func (r *postgresRepository) Create(user *domain.User) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
+func (r *postgresRepository) Create(ctx context.Context, user *domain.User) error {
+	_, span := tracing.Start(ctx, "UserRepository.Create")
+	defer span.End()
 
+	return runInTx(ctx, r.db, func(tx DBTX) error {
+		return r.create(ctx, tx, user)
+	})
+}
+
+func (r *postgresRepository) create(ctx context.Context, tx DBTX, user *domain.User) error {
 	if user.CreatedAt.IsZero() {
 		user.CreatedAt = time.Now()
 	}
@@ -80,7 +98,7 @@ func (r *postgresRepository) Create(user *domain.User) error {
 		RETURNING id
 	`
 
-	err = tx.QueryRow(
+	err := tx.QueryRowContext(ctx,
 		userQuery,
 		user.FullName,
 		user.Email,
@@ -89,6 +107,11 @@ func (r *postgresRepository) Create(user *domain.User) error {
 	).Scan(&user.ID)
 
 	if err != nil {
+		// A concurrent registration can claim the email between the
+		// usecase's EmailExists check and this insert.
+		if isUniqueViolation(err) {
+			return domain.ErrEmailTaken
+		}
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 
@@ -102,7 +125,7 @@ func (r *postgresRepository) Create(user *domain.User) error {
 			address = EXCLUDED.address
 		`
 
-	_, err = tx.Exec(
+	_, err = tx.ExecContext(ctx,
 		profileQuery,
 		user.ID,
 		user.Profile.FirstName,
@@ -121,15 +144,48 @@ func (r *postgresRepository) Create(user *domain.User) error {
 		ON CONFLICT (user_id, role) DO NOTHING
 	`
 
-	_, err = tx.Exec(roleQuery, user.ID, string(user.Role))
+	_, err = tx.ExecContext(ctx, roleQuery, user.ID, string(user.Role))
 	if err != nil {
 		return fmt.Errorf("failed to assign role: %w", err)
 	}
 
-	return tx.Commit()
+	return nil
 }
 
-func (r *postgresRepository) GetAll() ([]*domain.User, error) {
+// UpdateRole replaces the role of the user with the given id.
+func (r *postgresRepository) UpdateRole(ctx context.Context, userID int64, role domain.Role) error {
+	_, span := tracing.Start(ctx, "UserRepository.UpdateRole")
+	defer span.End()
+
+	return runInTx(ctx, r.db, func(tx DBTX) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM roles WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to revoke role: %w", err)
+		}
+
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO roles (user_id, role)
+			 SELECT id, $2 FROM users WHERE id = $1`,
+			userID,
+			string(role),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to assign role: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return domain.ErrUserNotFound
+		}
+		return nil
+	})
+}
+
+func (r *postgresRepository) GetAll(ctx context.Context) ([]*domain.User, error) {
+	_, span := tracing.Start(ctx, "UserRepository.GetAll")
+	defer span.End()
+
 	query := `
 		SELECT 
 			u.id, u.full_name, u.email, u.password, u.created_at,
@@ -141,7 +197,7 @@ func (r *postgresRepository) GetAll() ([]*domain.User, error) {
 		ORDER BY u.created_at DESC
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query users: %w", err)
 	}
@@ -163,15 +219,18 @@ func (r *postgresRepository) GetAll() ([]*domain.User, error) {
 	return users, nil
 }
 
-func (r *postgresRepository) GetUserWithProfile(userID int64) (*domain.User, error) {
-	return r.GetByID(userID)
+func (r *postgresRepository) GetUserWithProfile(ctx context.Context, userID int64) (*domain.User, error) {
+	return r.GetByID(ctx, userID)
 }
 
-func (r *postgresRepository) EmailExists(email string) (bool, error) {
+func (r *postgresRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	_, span := tracing.Start(ctx, "UserRepository.EmailExists")
+	defer span.End()
+
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
 	var exists bool
 	
-	err := r.db.QueryRow(query, email).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, query, email).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check email existence: %w", err)
 	}
@@ -179,13 +238,13 @@ func (r *postgresRepository) EmailExists(email string) (bool, error) {
 	return exists, nil
 }
 
-func (r *postgresRepository) scanUser(query string, args ...interface{}) (*domain.User, error) {
+func (r *postgresRepository) scanUser(ctx context.Context, query string, args ...interface{}) (*domain.User, error) {
 	var user domain.User
 	var firstName, lastName, address sql.NullString
 	var birthDate sql.NullTime
 	var role sql.NullString
 
-	err := r.db.QueryRow(query, args...).Scan(
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
 		&user.ID,
 		&user.FullName,
 		&user.Email,
@@ -258,3 +317,8 @@ func (r *postgresRepository) scanUserFromRows(rows *sql.Rows) (*domain.User, err
 
 	return &user, nil
 }
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"platform/tracing"
+	"time"
+	"user_service/domain"
+)
+
+type refreshTokenPostgres struct {
+	db DBTX
+}
+
+func NewRefreshTokenPostgres(db DBTX) RefreshTokenRepository {
+	return &refreshTokenPostgres{db: db}
+}
+
+func (r *refreshTokenPostgres) Create(ctx context.Context, token *domain.RefreshToken) error {
+	_, span := tracing.Start(ctx, "RefreshTokenRepository.Create")
+	defer span.End()
+
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO refresh_tokens (user_id, token_hash, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id`,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	).Scan(&token.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *refreshTokenPostgres) GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	_, span := tracing.Start(ctx, "RefreshTokenRepository.GetByHash")
+	defer span.End()
+
+	var t domain.RefreshToken
+	var revokedAt sql.NullTime
+	var replacedBy sql.NullInt64
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, user_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+		 FROM refresh_tokens
+		 WHERE token_hash = $1`,
+		tokenHash,
+	).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.TokenHash,
+		&t.ExpiresAt,
+		&revokedAt,
+		&replacedBy,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+	if replacedBy.Valid {
+		t.ReplacedBy = &replacedBy.Int64
+	}
+
+	return &t, nil
+}
+
+// Rotate revokes the old token and stores its successor in one transaction.
+// If the old token was already revoked (e.g. two concurrent refreshes with the
+// same token) nothing is written and ErrInvalidRefreshToken is returned.
+func (r *refreshTokenPostgres) Rotate(ctx context.Context, oldID int64, next *domain.RefreshToken) error {
+	_, span := tracing.Start(ctx, "RefreshTokenRepository.Rotate")
+	defer span.End()
+
+	return runInTx(ctx, r.db, func(tx DBTX) error {
+		return r.rotate(ctx, tx, oldID, next)
+	})
+}
+
+func (r *refreshTokenPostgres) rotate(ctx context.Context, tx DBTX, oldID int64, next *domain.RefreshToken) error {
+	if next.CreatedAt.IsZero() {
+		next.CreatedAt = time.Now()
+	}
+
+	err := tx.QueryRowContext(ctx,
+		`INSERT INTO refresh_tokens (user_id, token_hash, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id`,
+		next.UserID,
+		next.TokenHash,
+		next.ExpiresAt,
+		next.CreatedAt,
+	).Scan(&next.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE refresh_tokens
+		 SET revoked_at = $1, replaced_by = $2
+		 WHERE id = $3 AND revoked_at IS NULL`,
+		next.CreatedAt,
+		next.ID,
+		oldID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return domain.ErrInvalidRefreshToken
+	}
+
+	return nil
+}
+
+func (r *refreshTokenPostgres) Revoke(ctx context.Context, id int64) error {
+	_, span := tracing.Start(ctx, "RefreshTokenRepository.Revoke")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE refresh_tokens
+		 SET revoked_at = $1
+		 WHERE id = $2 AND revoked_at IS NULL`,
+		time.Now(),
+		id,
+	)
+	return err
+}
+
+func (r *refreshTokenPostgres) RevokeAllForUser(ctx context.Context, userID int64) error {
+	_, span := tracing.Start(ctx, "RefreshTokenRepository.RevokeAllForUser")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE refresh_tokens
+		 SET revoked_at = $1
+		 WHERE user_id = $2 AND revoked_at IS NULL`,
+		time.Now(),
+		userID,
+	)
+	return err
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"user_service/domain"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *domain.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	Rotate(ctx context.Context, oldID int64, next *domain.RefreshToken) error
+	Revoke(ctx context.Context, id int64) error
+	RevokeAllForUser(ctx context.Context, userID int64) error
+}
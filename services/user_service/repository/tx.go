@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"platform/tracing"
+)
+
+// DBTX is implemented by both *sql.DB and *sql.Tx, so a repository can run
+// on its own or as part of a caller's transaction.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Tx exposes repositories bound to a single database transaction.
+type Tx struct {
+	Users         UserRepository
+	RefreshTokens RefreshTokenRepository
+	Outbox        OutboxRepository
+}
+
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(tx *Tx) error) error
+}
+
+type postgresTransactor struct {
+	db *sql.DB
+}
+
+func NewTransactor(db *sql.DB) Transactor {
+	return &postgresTransactor{db: db}
+}
+
+func (t *postgresTransactor) WithinTx(ctx context.Context, fn func(tx *Tx) error) error {
+	ctx, span := tracing.StartChild(ctx, "Transaction")
+	defer span.End()
+
+	return runInTx(ctx, t.db, func(q DBTX) error {
+		return fn(&Tx{
+			Users:         NewPostgresRepository(q),
+			RefreshTokens: NewRefreshTokenPostgres(q),
+			Outbox:        NewOutboxPostgres(q),
+		})
+	})
+}
+
+// runInTx starts a transaction when db is a *sql.DB and joins the current one
+// when it already is a *sql.Tx.
+func runInTx(ctx context.Context, db DBTX, fn func(q DBTX) error) error {
+	conn, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -1,12 +1,16 @@
 package repository
 
-import "user_service/domain"
+import (
+	"context"
+	"user_service/domain"
+)
 
 type UserRepository interface {
-	GetByEmail(email string) (*domain.User, error)
-	GetByID(id int64) (*domain.User, error)
-	Create(user *domain.User) error
-	GetAll() ([]*domain.User, error)
-	GetUserWithProfile(userID int64) (*domain.User, error)
-	EmailExists(email string) (bool, error)
+	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	GetByID(ctx context.Context, id int64) (*domain.User, error)
+	Create(ctx context.Context, user *domain.User) error
+	UpdateRole(ctx context.Context, userID int64, role domain.Role) error
+	GetAll(ctx context.Context) ([]*domain.User, error)
+	GetUserWithProfile(ctx context.Context, userID int64) (*domain.User, error)
+	EmailExists(ctx context.Context, email string) (bool, error)
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"user_service/domain"
+	"user_service/repository"
+)
+
+var errSetRoleUsage = errors.New("usage: set-role EMAIL admin|worker|client")
+
+// runSetRole implements the set-role subcommand. Registration only creates
+// clients, so this is how the first admin comes to exist; after that admins
+// can use PUT /users/{id}/role.
+func runSetRole(ctx context.Context, db *sql.DB, args []string) error {
+	if len(args) != 2 {
+		return errSetRoleUsage
+	}
+	role := domain.Role(args[1])
+	if !role.IsValid() {
+		return fmt.Errorf("invalid role %q", args[1])
+	}
+
+	users := repository.NewPostgresRepository(db)
+	user, err := users.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(args[0])))
+	if err != nil {
+		return err
+	}
+	if err := users.UpdateRole(ctx, user.ID, role); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s is now %s\n", user.Email, role)
+	return nil
+}
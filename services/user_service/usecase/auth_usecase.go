@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"platform/logging"
+	"platform/tracing"
+	"user_service/domain"
+	"user_service/metrics"
+	"user_service/repository"
+)
+
+type TokenIssuer interface {
+	IssueAccessToken(user *domain.User) (string, time.Time, error)
+}
+
+type AuthUseCase interface {
+	Login(ctx context.Context, email, password string) (*domain.User, *domain.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+}
+
+type authUseCase struct {
+	userUC     UserUseCase
+	tokenRepo  repository.RefreshTokenRepository
+	issuer     TokenIssuer
+	refreshTTL time.Duration
+}
+
+func NewAuthUseCase(
+	userUC UserUseCase,
+	tokenRepo repository.RefreshTokenRepository,
+	issuer TokenIssuer,
+	refreshTTL time.Duration,
+) AuthUseCase {
+	return &authUseCase{
+		userUC:     userUC,
+		tokenRepo:  tokenRepo,
+		issuer:     issuer,
+		refreshTTL: refreshTTL,
+	}
+}
+
+func (uc *authUseCase) Login(ctx context.Context, email, password string) (*domain.User, *domain.TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.Login")
+	defer span.End()
+
+	user, err := uc.userUC.ValidateUserCredentials(ctx, email, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refresh, raw, err := uc.newRefreshToken(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := uc.tokenRepo.Create(ctx, refresh); err != nil {
+		return nil, nil, err
+	}
+
+	pair, err := uc.issuePair(user, refresh, raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metrics.Logins.WithLabelValues(metrics.LoginSucceeded).Inc()
+	slog.InfoContext(ctx, "user logged in", logging.UserID(user.ID))
+	return user, pair, nil
+}
+
+// Refresh exchanges a refresh token for a new access/refresh pair. Every
+// refresh token is single use: presenting one that was already rotated is
+// treated as theft and revokes every session of that user.
+func (uc *authUseCase) Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.Refresh")
+	defer span.End()
+
+	current, err := uc.lookup(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.IsRevoked() {
+		metrics.RefreshTokenReuse.Inc()
+		slog.WarnContext(ctx, "refresh token reused, revoking all sessions", logging.UserID(current.UserID))
+		if err := uc.tokenRepo.RevokeAllForUser(ctx, current.UserID); err != nil {
+			return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if current.IsExpired(time.Now()) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	user, err := uc.userUC.GetUserByID(ctx, current.UserID)
+	if err != nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	next, raw, err := uc.newRefreshToken(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.tokenRepo.Rotate(ctx, current.ID, next); err != nil {
+		return nil, err
+	}
+
+	return uc.issuePair(user, next, raw)
+}
+
+func (uc *authUseCase) Logout(ctx context.Context, refreshToken string) error {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.Logout")
+	defer span.End()
+
+	current, err := uc.lookup(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+
+	if current.IsRevoked() {
+		return nil
+	}
+
+	return uc.tokenRepo.Revoke(ctx, current.ID)
+}
+
+func (uc *authUseCase) lookup(ctx context.Context, refreshToken string) (*domain.RefreshToken, error) {
+	refreshToken = strings.TrimSpace(refreshToken)
+	if refreshToken == "" {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	return uc.tokenRepo.GetByHash(ctx, hashToken(refreshToken))
+}
+
+func (uc *authUseCase) issuePair(user *domain.User, refresh *domain.RefreshToken, raw string) (*domain.TokenPair, error) {
+	access, accessExp, err := uc.issuer.IssueAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TokenPair{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExp,
+		RefreshToken:     raw,
+		RefreshExpiresAt: refresh.ExpiresAt,
+	}, nil
+}
+
+// newRefreshToken returns the persisted record and the raw token handed to
+// the client. Only the SHA-256 of the raw value is stored.
+func (uc *authUseCase) newRefreshToken(userID int64) (*domain.RefreshToken, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now()
+	return &domain.RefreshToken{
+		UserID:    userID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(uc.refreshTTL),
+		CreatedAt: now,
+	}, raw, nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
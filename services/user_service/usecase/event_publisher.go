@@ -1,6 +0,0 @@
-package usecase
-
-type EventPublisher interface {
-	Publish(eventName string, payload []byte) error
-}
-
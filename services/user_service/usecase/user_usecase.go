@@ -1,41 +1,47 @@
 package usecase
 
 import (
-	"user_service/domain"
-	"user_service/repository"
+	"platform/logging"
+
+	"context"
 	"crypto/rand"
 	"crypto/subtle"
 	"encoding/base64"
 	"errors"
+	"events"
 	"fmt"
+	"log/slog"
+	"platform/tracing"
 	"strings"
 	"time"
+	"user_service/domain"
+	"user_service/metrics"
+	"user_service/repository"
 
 	"golang.org/x/crypto/argon2"
-	"encoding/json"
-
 )
 
 type UserUseCase interface {
-	Register(email, password, fullName string, role domain.Role, profile domain.Profile) (*domain.User, error)
-	Login(email, password string) (*domain.User, error)
-	GetUserByID(id int64) (*domain.User, error)
-	GetAllUsers() ([]*domain.User, error)
-	ValidateUserCredentials(email, password string) (*domain.User, error)
+	Register(ctx context.Context, email, password, fullName string, profile domain.Profile) (*domain.User, error)
+	ChangeRole(ctx context.Context, actor domain.Actor, userID int64, role domain.Role) (*domain.User, error)
+	Login(ctx context.Context, email, password string) (*domain.User, error)
+	GetUserByID(ctx context.Context, id int64) (*domain.User, error)
+	GetAllUsers(ctx context.Context) ([]*domain.User, error)
+	ValidateUserCredentials(ctx context.Context, email, password string) (*domain.User, error)
 }
 
 type userUseCase struct {
-	userRepo repository.UserRepository
-	publisher EventPublisher
+	userRepo   repository.UserRepository
+	transactor repository.Transactor
 }
 
 func NewUserUseCase(
 	userRepo repository.UserRepository,
-	publisher EventPublisher,
+	transactor repository.Transactor,
 ) UserUseCase {
 	return &userUseCase{
-		userRepo: userRepo,
-		publisher: publisher,
+		userRepo:   userRepo,
+		transactor: transactor,
 	}
 }
 
@@ -55,31 +61,33 @@ var argon2Config = &argon2Params{
 	keyLength:   32,
 }
 
-func (uc *userUseCase) Register(email, password, fullName string, role domain.Role, profile domain.Profile) (*domain.User, error) {
+// Register signs up a new client. Other roles are only granted by an admin
+// through ChangeRole.
+func (uc *userUseCase) Register(ctx context.Context, email, password, fullName string, profile domain.Profile) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Register")
+	defer span.End()
+
 	// 1. Validate inputs
 	if strings.TrimSpace(email) == "" {
-		return nil, errors.New("email is required")
+		return nil, domain.Invalid("email is required")
 	}
 	if strings.TrimSpace(password) == "" {
-		return nil, errors.New("password is required")
+		return nil, domain.Invalid("password is required")
 	}
 	if strings.TrimSpace(fullName) == "" {
-		return nil, errors.New("full name is required")
-	}
-	if !role.IsValid() {
-		return nil, errors.New("invalid role")
+		return nil, domain.Invalid("full name is required")
 	}
 	if len(password) < 8 {
-		return nil, errors.New("password must be at least 8 characters")
+		return nil, domain.Invalid("password must be at least 8 characters")
 	}
 
 	// 2. Check if email already exists
-	exists, err := uc.userRepo.EmailExists(email)
+	exists, err := uc.userRepo.EmailExists(ctx, email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check email: %w", err)
 	}
 	if exists {
-		return nil, errors.New("email already registered")
+		return nil, domain.ErrEmailTaken
 	}
 
 	// 3. Hash password with Argon2
@@ -93,44 +101,59 @@ func (uc *userUseCase) Register(email, password, fullName string, role domain.Ro
 		FullName:  strings.TrimSpace(fullName),
 		Email:     strings.ToLower(strings.TrimSpace(email)),
 		Password:  hashedPassword,
-		Role:      role,
+		Role:      domain.RoleClient,
 		Profile:   profile,
 		CreatedAt: time.Now(),
 	}
 
-	// 5. Save to repository
-	err = uc.userRepo.Create(user)
+	// 5. Save to repository together with the user.registered event
+	err = uc.transactor.WithinTx(ctx, func(tx *repository.Tx) error {
+		if err := tx.Users.Create(ctx, user); err != nil {
+			return err
+		}
+
+		event, err := events.New(domain.ServiceName, events.UserRegistered, events.UserRegisteredV1{
+			UserID: user.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return tx.Outbox.Add(ctx, event)
+	})
+	if errors.Is(err, domain.ErrEmailTaken) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-
-	if uc.publisher != nil {
-	event := map[string]interface{}{
-		"user_id": user.ID,
-	}
-
-	data, _ := json.Marshal(event)
-	_ = uc.publisher.Publish("user.registered", data)
-}
-
+	metrics.UsersRegistered.WithLabelValues(string(user.Role)).Inc()
+	slog.InfoContext(ctx, "user registered", logging.UserID(user.ID), slog.String("role", string(user.Role)))
 
 	// 6. Clear sensitive data before returning
 	user.Password = ""
 	return user, nil
 }
 
-func (uc *userUseCase) Login(email, password string) (*domain.User, error) {
+func (uc *userUseCase) Login(ctx context.Context, email, password string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Login")
+	defer span.End()
+
 	// 1. Validate inputs
 	if strings.TrimSpace(email) == "" || strings.TrimSpace(password) == "" {
-		return nil, errors.New("email and password are required")
+		return nil, domain.Invalid("email and password are required")
 	}
 
 	// 2. Get user from repository
-	user, err := uc.userRepo.GetByEmail(strings.ToLower(strings.TrimSpace(email)))
-	if err != nil {
+	user, err := uc.userRepo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, domain.ErrUserNotFound) {
 		// Security: Return generic error to avoid user enumeration
-		return nil, errors.New("invalid credentials")
+		metrics.Logins.WithLabelValues(metrics.LoginRejected).Inc()
+		return nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	// 3. Verify password with Argon2
@@ -139,7 +162,9 @@ func (uc *userUseCase) Login(email, password string) (*domain.User, error) {
 		return nil, fmt.Errorf("password verification failed: %w", err)
 	}
 	if !valid {
-		return nil, errors.New("invalid credentials")
+		metrics.Logins.WithLabelValues(metrics.LoginRejected).Inc()
+		slog.WarnContext(ctx, "login rejected: wrong password", logging.UserID(user.ID))
+		return nil, domain.ErrInvalidCredentials
 	}
 
 	// 5. Clear sensitive data before returning
@@ -147,18 +172,24 @@ func (uc *userUseCase) Login(email, password string) (*domain.User, error) {
 	return user, nil
 }
 
-func (uc *userUseCase) ValidateUserCredentials(email, password string) (*domain.User, error) {
-	return uc.Login(email, password)
+func (uc *userUseCase) ValidateUserCredentials(ctx context.Context, email, password string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.ValidateUserCredentials")
+	defer span.End()
+
+	return uc.Login(ctx, email, password)
 }
 
-func (uc *userUseCase) GetUserByID(id int64) (*domain.User, error) {
+func (uc *userUseCase) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetUserByID")
+	defer span.End()
+
 	if id <= 0 {
-		return nil, errors.New("invalid user ID")
+		return nil, domain.Invalid("invalid user ID")
 	}
 
-	user, err := uc.userRepo.GetByID(id)
+	user, err := uc.userRepo.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, err
 	}
 
 	// Clear sensitive data
@@ -166,8 +197,35 @@ func (uc *userUseCase) GetUserByID(id int64) (*domain.User, error) {
 	return user, nil
 }
 
-func (uc *userUseCase) GetAllUsers() ([]*domain.User, error) {
-	users, err := uc.userRepo.GetAll()
+// ChangeRole gives the user a new role. Only admins may do this; the user
+// gets the role in the next access token they obtain.
+func (uc *userUseCase) ChangeRole(ctx context.Context, actor domain.Actor, userID int64, role domain.Role) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.ChangeRole")
+	defer span.End()
+
+	if !actor.IsAdmin() {
+		return nil, domain.ErrForbidden
+	}
+	if userID <= 0 {
+		return nil, domain.Invalid("invalid user ID")
+	}
+	if !role.IsValid() {
+		return nil, domain.Invalid("invalid role")
+	}
+
+	if err := uc.userRepo.UpdateRole(ctx, userID, role); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "user role changed", logging.UserID(userID), slog.String("role", string(role)), slog.Int64("changed_by", actor.UserID))
+
+	return uc.GetUserByID(ctx, userID)
+}
+
+func (uc *userUseCase) GetAllUsers(ctx context.Context) ([]*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetAllUsers")
+	defer span.End()
+
+	users, err := uc.userRepo.GetAll(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users: %w", err)
 	}